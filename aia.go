@@ -0,0 +1,58 @@
+package gocsp
+
+import (
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+)
+
+var ErrResponderNotInAIA = errors.New("responder URL is not listed in the certificate authority information access")
+
+// CheckResponderURL checks that responderURL is one of the OCSP responders listed in the
+// authority information access extension of cert.
+//
+// Clients that pin the responder this way refuse to query a responder the certificate does not point to,
+// for example one taken from an attacker-controlled configuration. URLs are compared after normalizing
+// the scheme and host case, default ports and trailing slashes.
+func CheckResponderURL(cert *x509.Certificate, responderURL string) error {
+	want, err := normalizeResponderURL(responderURL)
+	if err != nil {
+		return err
+	}
+	for _, server := range cert.OCSPServer {
+		got, err := normalizeResponderURL(server)
+		if err != nil {
+			continue
+		}
+		if got == want {
+			return nil
+		}
+	}
+	return ErrResponderNotInAIA
+}
+
+// normalizeResponderURL returns a canonical form of an HTTP responder URL for comparison.
+func normalizeResponderURL(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	scheme := strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if (scheme == "http" && port == "80") || (scheme == "https" && port == "443") {
+		port = ""
+	}
+	if port != "" {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	normalized := scheme + "://" + host + strings.TrimSuffix(u.EscapedPath(), "/")
+	if u.RawQuery != "" {
+		normalized += "?" + u.RawQuery
+	}
+	return normalized, nil
+}
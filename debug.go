@@ -0,0 +1,235 @@
+package gocsp
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// oidNames maps the object identifiers used in OCSP messages to readable names for DebugJSON.
+var oidNames = map[string]string{
+	"1.3.6.1.5.5.7.48.1.1":    "basicResponse",
+	"1.3.6.1.5.5.7.48.1.2":    "nonce",
+	"1.3.6.1.5.5.7.48.1.3":    "crlReferences",
+	"1.3.6.1.5.5.7.48.1.4":    "acceptableResponses",
+	"1.3.6.1.5.5.7.48.1.6":    "archiveCutoff",
+	"1.3.6.1.5.5.7.48.1.7":    "serviceLocator",
+	"1.3.6.1.5.5.7.48.1.8":    "preferredSignatureAlgorithms",
+	"1.3.6.1.5.5.7.48.1.9":    "extendedRevoke",
+	"1.3.14.3.2.26":           "sha1",
+	"2.16.840.1.101.3.4.2.1":  "sha256",
+	"2.16.840.1.101.3.4.2.2":  "sha384",
+	"2.16.840.1.101.3.4.2.3":  "sha512",
+	"2.16.840.1.101.3.4.2.8":  "sha3-256",
+	"2.16.840.1.101.3.4.2.9":  "sha3-384",
+	"2.16.840.1.101.3.4.2.10": "sha3-512",
+	"1.2.840.113549.1.1.5":    "sha1WithRSAEncryption",
+	"1.2.840.113549.1.1.10":   "rsassaPss",
+	"1.2.840.113549.1.1.11":   "sha256WithRSAEncryption",
+	"1.2.840.113549.1.1.12":   "sha384WithRSAEncryption",
+	"1.2.840.113549.1.1.13":   "sha512WithRSAEncryption",
+	"1.2.840.10045.4.1":       "ecdsa-with-SHA1",
+	"1.2.840.10045.4.3.2":     "ecdsa-with-SHA256",
+	"1.2.840.10045.4.3.3":     "ecdsa-with-SHA384",
+	"1.2.840.10045.4.3.4":     "ecdsa-with-SHA512",
+	"1.3.101.112":             "ed25519",
+}
+
+type debugOID struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+type debugExtension struct {
+	debugOID
+	Critical bool   `json:"critical"`
+	Value    string `json:"value"`
+}
+
+type debugCertID struct {
+	HashAlgorithm debugOID `json:"hashAlgorithm"`
+	NameHash      string   `json:"nameHash"`
+	IssuerKeyHash string   `json:"issuerKeyHash"`
+	SerialNumber  string   `json:"serialNumber"`
+}
+
+type debugSingleRequest struct {
+	CertID     debugCertID      `json:"certID"`
+	Extensions []debugExtension `json:"extensions,omitempty"`
+}
+
+type debugSignature struct {
+	Algorithm debugOID `json:"algorithm"`
+	Value     string   `json:"value"`
+	Certs     int      `json:"certs"`
+}
+
+type debugRequest struct {
+	Version       int                  `json:"version"`
+	RequestorName string               `json:"requestorName,omitempty"`
+	Requests      []debugSingleRequest `json:"requests"`
+	Extensions    []debugExtension     `json:"extensions,omitempty"`
+	Signature     *debugSignature      `json:"signature,omitempty"`
+}
+
+type debugRevokedInfo struct {
+	RevocationTime   time.Time `json:"revocationTime"`
+	RevocationReason int       `json:"revocationReason"`
+}
+
+type debugSingleResponse struct {
+	CertID     debugCertID       `json:"certID"`
+	Status     string            `json:"status"`
+	Revoked    *debugRevokedInfo `json:"revoked,omitempty"`
+	ThisUpdate time.Time         `json:"thisUpdate"`
+	NextUpdate *time.Time        `json:"nextUpdate,omitempty"`
+	Extensions []debugExtension  `json:"extensions,omitempty"`
+}
+
+type debugBasicResponse struct {
+	Version            int                   `json:"version"`
+	ResponderID        string                `json:"responderID"`
+	ProducedAt         time.Time             `json:"producedAt"`
+	Responses          []debugSingleResponse `json:"responses"`
+	Extensions         []debugExtension      `json:"extensions,omitempty"`
+	SignatureAlgorithm debugOID              `json:"signatureAlgorithm"`
+	Signature          string                `json:"signature"`
+	Certs              int                   `json:"certs"`
+}
+
+type debugResponse struct {
+	ResponseStatus int                 `json:"responseStatus"`
+	ResponseType   *debugOID           `json:"responseType,omitempty"`
+	BasicResponse  *debugBasicResponse `json:"basicResponse,omitempty"`
+}
+
+// DebugJSON renders all decoded fields of the request as indented JSON for troubleshooting.
+//
+// Object identifiers are shown with their name where known, and hashes, signatures and extension values are hex-encoded.
+func (r *OcspRequest) DebugJSON() ([]byte, error) {
+	d := debugRequest{
+		Version:    r.TBSRequest.Version,
+		Extensions: debugExtensions(r.TBSRequest.ExtensionList),
+	}
+	if name, ok := r.RequestorNameString(); ok {
+		d.RequestorName = name
+	}
+	for _, singleRequest := range r.TBSRequest.RequestList {
+		d.Requests = append(d.Requests, debugSingleRequest{
+			CertID:     debugCertIDOf(&singleRequest.ReqCert),
+			Extensions: debugExtensions(singleRequest.SingleRequestExtensions),
+		})
+	}
+	if len(r.Signature.SignatureAlgorithm.Algorithm) > 0 {
+		d.Signature = &debugSignature{
+			Algorithm: debugOIDOf(r.Signature.SignatureAlgorithm.Algorithm),
+			Value:     hex.EncodeToString(r.Signature.Signature.Bytes),
+			Certs:     len(r.Signature.Certs),
+		}
+	}
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// DebugJSON renders all decoded fields of the response as indented JSON for troubleshooting.
+//
+// If the response carries a basic response, it is decoded and rendered as well.
+func (r *OcspResponse) DebugJSON() ([]byte, error) {
+	d := debugResponse{ResponseStatus: int(r.ResponseStatus)}
+	if len(r.ResponseBytes.ResponseType) > 0 {
+		responseType := debugOIDOf(r.ResponseBytes.ResponseType)
+		d.ResponseType = &responseType
+		if r.ResponseBytes.ResponseType.Equal(OidOcspBasicResponse) {
+			basicResponse, err := UnmarshalBasicResponse(r.ResponseBytes.Response)
+			if err != nil {
+				return nil, err
+			}
+			d.BasicResponse = basicResponse.debug()
+		}
+	}
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// DebugJSON renders all decoded fields of the basic response as indented JSON for troubleshooting.
+func (basicResponse *BasicResponse) DebugJSON() ([]byte, error) {
+	return json.MarshalIndent(basicResponse.debug(), "", "  ")
+}
+
+func (basicResponse *BasicResponse) debug() *debugBasicResponse {
+	tbs := &basicResponse.TBSResponseData
+	d := &debugBasicResponse{
+		Version:            tbs.Version,
+		ResponderID:        debugResponderID(tbs.ResponderID),
+		ProducedAt:         tbs.ProducedAt,
+		Extensions:         debugExtensions(tbs.ResponseExtensions),
+		SignatureAlgorithm: debugOIDOf(basicResponse.SignatureAlgorithm.Algorithm),
+		Signature:          hex.EncodeToString(basicResponse.Signature.Bytes),
+		Certs:              len(basicResponse.Certs),
+	}
+	for _, sr := range tbs.Responses {
+		s := debugSingleResponse{
+			CertID:     debugCertIDOf(&sr.CertID),
+			ThisUpdate: sr.ThisUpdate,
+			Extensions: debugExtensions(sr.SingleExtensions),
+		}
+		s.Status = sr.status().String()
+		if sr.status() == StatusRevoked {
+			s.Revoked = &debugRevokedInfo{
+				RevocationTime:   sr.Revoked.RevocationTime,
+				RevocationReason: int(sr.Revoked.RevocationReason),
+			}
+		}
+		if !sr.NextUpdate.IsZero() {
+			nextUpdate := sr.NextUpdate
+			s.NextUpdate = &nextUpdate
+		}
+		d.Responses = append(d.Responses, s)
+	}
+	return d
+}
+
+func debugOIDOf(oid asn1.ObjectIdentifier) debugOID {
+	return debugOID{ID: oid.String(), Name: oidNames[oid.String()]}
+}
+
+func debugCertIDOf(id *CertID) debugCertID {
+	d := debugCertID{
+		HashAlgorithm: debugOIDOf(id.HashAlgorithm.Algorithm),
+		NameHash:      hex.EncodeToString(id.NameHash),
+		IssuerKeyHash: hex.EncodeToString(id.IssuerKeyHash),
+	}
+	if id.SerialNumber != nil {
+		d.SerialNumber = id.SerialNumber.Text(16)
+	}
+	return d
+}
+
+func debugExtensions(extensions []pkix.Extension) []debugExtension {
+	var d []debugExtension
+	for _, extension := range extensions {
+		d = append(d, debugExtension{
+			debugOID: debugOIDOf(extension.Id),
+			Critical: extension.Critical,
+			Value:    hex.EncodeToString(extension.Value),
+		})
+	}
+	return d
+}
+
+// debugResponderID renders the ResponderID CHOICE as either the responder name or its hex key hash.
+func debugResponderID(responderID asn1.RawValue) string {
+	switch {
+	case responderID.Class == asn1.ClassContextSpecific && responderID.Tag == 1:
+		var name pkix.RDNSequence
+		if _, err := asn1.Unmarshal(responderID.Bytes, &name); err == nil {
+			return "byName: " + name.String()
+		}
+	case responderID.Class == asn1.ClassContextSpecific && responderID.Tag == 2:
+		var keyHash []byte
+		if _, err := asn1.Unmarshal(responderID.Bytes, &keyHash); err == nil {
+			return "byKey: " + hex.EncodeToString(keyHash)
+		}
+	}
+	return hex.EncodeToString(responderID.FullBytes)
+}
@@ -0,0 +1,108 @@
+package gocsp
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"encoding/asn1"
+	"errors"
+	"io"
+	"math/big"
+)
+
+// https://tools.ietf.org/html/rfc6979#section-3.2
+
+// deterministicECDSASigner signs with an ECDSA key using the deterministic nonce of RFC 6979,
+// so that signing the same digest twice gives the same signature.
+//
+// It is only meant for tests and reproducible output. The scalar multiplication is not constant time.
+// Since Go 1.24, (*ecdsa.PrivateKey).Sign with a nil random source gives the same signatures; this
+// implementation only exists because go.mod still supports Go 1.22.
+type deterministicECDSASigner struct {
+	key *ecdsa.PrivateKey
+}
+
+func (s deterministicECDSASigner) Public() crypto.PublicKey {
+	return &s.key.PublicKey
+}
+
+// Sign ignores rand and returns the DER ECDSA-Sig-Value of digest, which was hashed with opts.HashFunc().
+func (s deterministicECDSASigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	hash := opts.HashFunc()
+	if !hash.Available() {
+		return nil, errUnsupportedHash
+	}
+	curve := s.key.Curve
+	n := curve.Params().N
+	qlen := n.BitLen()
+	rlen := (qlen + 7) / 8
+	bits2int := func(b []byte) *big.Int {
+		v := new(big.Int).SetBytes(b)
+		if blen := len(b) * 8; blen > qlen {
+			v.Rsh(v, uint(blen-qlen))
+		}
+		return v
+	}
+	int2octets := func(v *big.Int) []byte {
+		return v.FillBytes(make([]byte, rlen))
+	}
+	e := bits2int(digest)
+	h1 := new(big.Int).Mod(e, n)
+	mac := func(key []byte, data ...[]byte) []byte {
+		m := hmac.New(hash.New, key)
+		for _, d := range data {
+			m.Write(d)
+		}
+		return m.Sum(nil)
+	}
+
+	x := int2octets(s.key.D)
+	v := make([]byte, hash.Size())
+	for i := range v {
+		v[i] = 0x01
+	}
+	k := make([]byte, hash.Size())
+	k = mac(k, v, []byte{0x00}, x, int2octets(h1))
+	v = mac(k, v)
+	k = mac(k, v, []byte{0x01}, x, int2octets(h1))
+	v = mac(k, v)
+	for {
+		var t []byte
+		for len(t)*8 < qlen {
+			v = mac(k, v)
+			t = append(t, v...)
+		}
+		nonce := bits2int(t)
+		if nonce.Sign() > 0 && nonce.Cmp(n) < 0 {
+			rx, _ := curve.ScalarBaseMult(int2octets(nonce))
+			r := new(big.Int).Mod(rx, n)
+			if r.Sign() != 0 {
+				// s = nonce^-1 * (e + r*d) mod n
+				sv := new(big.Int).Mul(r, s.key.D)
+				sv.Add(sv, e)
+				sv.Mul(sv, new(big.Int).ModInverse(nonce, n))
+				sv.Mod(sv, n)
+				if sv.Sign() != 0 {
+					return asn1.Marshal(struct{ R, S *big.Int }{r, sv})
+				}
+			}
+		}
+		k = mac(k, v, []byte{0x00})
+		v = mac(k, v)
+	}
+}
+
+// deterministicSigner returns a signer producing reproducible signatures with the key of signer.
+//
+// RSA PKCS #1 v1.5 and Ed25519 signatures are already deterministic, so signer is returned as is for them.
+// An ECDSA signer must be an *ecdsa.PrivateKey, since a nonce cannot be chosen for keys held elsewhere.
+func deterministicSigner(signer crypto.Signer) (crypto.Signer, error) {
+	if _, ok := signer.Public().(*ecdsa.PublicKey); !ok {
+		return signer, nil
+	}
+	key, ok := signer.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("deterministic ECDSA signing requires an *ecdsa.PrivateKey")
+	}
+	return deterministicECDSASigner{key: key}, nil
+}
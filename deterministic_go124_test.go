@@ -0,0 +1,43 @@
+//go:build go1.24
+
+package gocsp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+)
+
+// Since Go 1.24, (*ecdsa.PrivateKey).Sign with a nil random source is deterministic per RFC 6979,
+// which gives an independent implementation to compare with.
+func TestDeterministicECDSAMatchesStandardLibrary(t *testing.T) {
+	curves := []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521()}
+	hashes := []crypto.Hash{crypto.SHA1, crypto.SHA256, crypto.SHA384, crypto.SHA512}
+	for _, curve := range curves {
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, hash := range hashes {
+			t.Run(curve.Params().Name+"/"+hash.String(), func(t *testing.T) {
+				h := hash.New()
+				h.Write([]byte("sample"))
+				digest := h.Sum(nil)
+				got, err := deterministicECDSASigner{key: key}.Sign(nil, digest, hash)
+				if err != nil {
+					t.Fatal(err)
+				}
+				want, err := key.Sign(nil, digest, hash)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Errorf("signature = %x, want %x", got, want)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package gocsp
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// rfc6979P256Key is the P-256 private key of RFC 6979 appendix A.2.5.
+func rfc6979P256Key(t testing.TB) *ecdsa.PrivateKey {
+	t.Helper()
+	d, ok := new(big.Int).SetString("C9AFA9D845BA75166B5C215767B1D6934E50C3DB36E89B127B8A622B120F6721", 16)
+	if !ok {
+		t.Fatal("invalid private key")
+	}
+	key := &ecdsa.PrivateKey{D: d}
+	key.Curve = elliptic.P256()
+	key.X, key.Y = key.Curve.ScalarBaseMult(d.Bytes())
+	return key
+}
+
+func TestDeterministicECDSAKnownAnswers(t *testing.T) {
+	// RFC 6979 appendix A.2.5, P-256 with SHA-256.
+	tests := []struct {
+		message string
+		r, s    string
+	}{
+		{
+			"sample",
+			"EFD48B2AACB6A8FD1140DD9CD45E81D69D2C877B56AAF991C34D0EA84EAF3716",
+			"F7CB1C942D657C41D436C7A1B6E29F65F3E900DBB9AFF4064DC4AB2F843ACDA8",
+		},
+		{
+			"test",
+			"F1ABB023518351CD71D881567B1EA663ED3EFCF6C5132B354F28D3B0B7D38367",
+			"019F4113742A2B14BD25926B49C649155F267E60D3814B4C0CC84250E46F0083",
+		},
+	}
+	signer := deterministicECDSASigner{key: rfc6979P256Key(t)}
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			digest := sha256.Sum256([]byte(tt.message))
+			sig, err := signer.Sign(nil, digest[:], crypto.SHA256)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got struct{ R, S *big.Int }
+			if _, err := asn1.Unmarshal(sig, &got); err != nil {
+				t.Fatal(err)
+			}
+			r, _ := new(big.Int).SetString(tt.r, 16)
+			s, _ := new(big.Int).SetString(tt.s, 16)
+			if got.R.Cmp(r) != 0 || got.S.Cmp(s) != 0 {
+				t.Errorf("signature = (%X, %X), want (%s, %s)", got.R, got.S, tt.r, tt.s)
+			}
+		})
+	}
+}
+
+// The response names the test CA as its responder but is signed with the RFC 6979 key,
+// so only its bytes and its signature under that key are checked.
+func TestSignBasicResponseDeterministicGolden(t *testing.T) {
+	id, err := NewCertID(crypto.SHA1, testIssuer(t), big.NewInt(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	br := &BasicResponse{}
+	br.TBSResponseData.ResponderID = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 1, IsCompound: true, Bytes: testIssuer(t).RawSubject}
+	br.TBSResponseData.ProducedAt = at
+	i := br.AddSingleResponse(id, at, at.Add(24*time.Hour))
+	br.TBSResponseData.Responses[i].Good = true
+	key := rfc6979P256Key(t)
+	if err := SignBasicResponseWithOptions(context.Background(), br, key, SignOptions{DeterministicECDSA: true}); err != nil {
+		t.Fatal(err)
+	}
+	der, err := MarshalResponseFromBasic(br)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := readTestFile(t, "resp-deterministic.der"); !bytes.Equal(der, want) {
+		t.Errorf("signed response = %x, want %x", der, want)
+	}
+	if err := VerifyWithKey(br, key.Public()); err != nil {
+		t.Errorf("VerifyWithKey: %v", err)
+	}
+}
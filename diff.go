@@ -0,0 +1,60 @@
+package gocsp
+
+import (
+	"math/big"
+)
+
+// StatusChange describes how the status of one serial number differs between two basic responses.
+type StatusChange struct {
+	SerialNumber *big.Int
+	OldStatus    CertStatus
+	NewStatus    CertStatus
+	// OldRevokedInfo and NewRevokedInfo are only set for a revoked status.
+	OldRevokedInfo RevokedInfo
+	NewRevokedInfo RevokedInfo
+}
+
+// DiffResponses compares the single responses of old and new by serial number, for monitoring the
+// revocation status of certificates over time.
+//
+// A change is reported when the status differs, for example good to revoked, or when both are revoked with a
+// different revocation time or reason. Changes are returned in the order of the single responses of new.
+// Serial numbers present in only one of the responses are not reported, and serial numbers are assumed to be
+// unique within a response, as when monitoring certificates of a single issuer.
+func DiffResponses(old, new *BasicResponse) []StatusChange {
+	oldBySerial := make(map[string]*SingleResponse, len(old.TBSResponseData.Responses))
+	for i := range old.TBSResponseData.Responses {
+		sr := &old.TBSResponseData.Responses[i]
+		if sr.CertID.SerialNumber != nil {
+			oldBySerial[sr.CertID.SerialNumber.String()] = sr
+		}
+	}
+	var changes []StatusChange
+	for i := range new.TBSResponseData.Responses {
+		newSR := &new.TBSResponseData.Responses[i]
+		if newSR.CertID.SerialNumber == nil {
+			continue
+		}
+		oldSR, ok := oldBySerial[newSR.CertID.SerialNumber.String()]
+		if !ok {
+			continue
+		}
+		change := StatusChange{
+			SerialNumber: newSR.CertID.SerialNumber,
+			OldStatus:    oldSR.status(),
+			NewStatus:    newSR.status(),
+		}
+		if change.OldStatus == StatusRevoked {
+			change.OldRevokedInfo = oldSR.Revoked
+		}
+		if change.NewStatus == StatusRevoked {
+			change.NewRevokedInfo = newSR.Revoked
+		}
+		if change.OldStatus != change.NewStatus ||
+			!change.OldRevokedInfo.RevocationTime.Equal(change.NewRevokedInfo.RevocationTime) ||
+			change.OldRevokedInfo.RevocationReason != change.NewRevokedInfo.RevocationReason {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
@@ -0,0 +1,50 @@
+package gocsp
+
+import (
+	"encoding/asn1"
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrResponseRollback = errors.New("OCSP response was produced before a response already seen for the same certificate")
+
+// FreshnessTracker remembers the latest ProducedAt seen for each certID, so that a client caching
+// responses can detect a responder, cache or attacker serving an older response than before.
+//
+// The zero value is ready to use, and a FreshnessTracker is safe for concurrent use.
+type FreshnessTracker struct {
+	mu       sync.Mutex
+	lastSeen map[string]time.Time
+}
+
+// Observe records the ProducedAt of the basic response for every certID it answers.
+//
+// It returns ErrResponseRollback without recording anything if the response was produced before
+// the last response seen for any of these certIDs. A response produced at the same time is accepted.
+func (t *FreshnessTracker) Observe(br *BasicResponse) error {
+	producedAt := br.TBSResponseData.ProducedAt
+	keys := make([]string, 0, len(br.TBSResponseData.Responses))
+	for _, sr := range br.TBSResponseData.Responses {
+		key, err := asn1.Marshal(sr.CertID)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, string(key))
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, key := range keys {
+		if last, ok := t.lastSeen[key]; ok && producedAt.Before(last) {
+			return ErrResponseRollback
+		}
+	}
+	if t.lastSeen == nil {
+		t.lastSeen = make(map[string]time.Time)
+	}
+	for _, key := range keys {
+		t.lastSeen[key] = producedAt
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package gocsp
+
+import (
+	"crypto"
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+)
+
+// hashOIDs maps the hash algorithms supported in certIDs to their object identifier.
+//
+// The SHA-3 family is only usable when the caller selects it and links an implementation,
+// for example by importing golang.org/x/crypto/sha3, as few responders support it.
+var hashOIDs = map[crypto.Hash]asn1.ObjectIdentifier{
+	crypto.SHA1:     {1, 3, 14, 3, 2, 26},
+	crypto.SHA256:   {2, 16, 840, 1, 101, 3, 4, 2, 1},
+	crypto.SHA384:   {2, 16, 840, 1, 101, 3, 4, 2, 2},
+	crypto.SHA512:   {2, 16, 840, 1, 101, 3, 4, 2, 3},
+	crypto.SHA3_256: {2, 16, 840, 1, 101, 3, 4, 2, 8},
+	crypto.SHA3_384: {2, 16, 840, 1, 101, 3, 4, 2, 9},
+	crypto.SHA3_512: {2, 16, 840, 1, 101, 3, 4, 2, 10},
+}
+
+var errUnsupportedHash = errors.New("unsupported hash algorithm for OCSP certID")
+
+// hashFromOID returns the hash algorithm identified by oid, or 0 if it is not supported.
+func hashFromOID(oid asn1.ObjectIdentifier) crypto.Hash {
+	for hash, hashOID := range hashOIDs {
+		if oid.Equal(hashOID) {
+			return hash
+		}
+	}
+	return 0
+}
+
+// hashAlgorithmIdentifier returns the canonical certID hashAlgorithm for hash.
+//
+// The parameters are always an explicit NULL. RFC 5754 allows them to be absent for the SHA-2
+// family, but NULL is what OpenSSL emits and what mainstream responders match against;
+// a certID with absent parameters is answered with unknown by some of them.
+func hashAlgorithmIdentifier(hash crypto.Hash) (pkix.AlgorithmIdentifier, error) {
+	oid, ok := hashOIDs[hash]
+	if !ok {
+		return pkix.AlgorithmIdentifier{}, errUnsupportedHash
+	}
+	return pkix.AlgorithmIdentifier{Algorithm: oid, Parameters: asn1.NullRawValue}, nil
+}
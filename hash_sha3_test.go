@@ -0,0 +1,6 @@
+//go:build go1.24
+
+package gocsp
+
+// Link the standard library SHA-3 so that the SHA-3 certID tests run.
+import _ "crypto/sha3"
@@ -0,0 +1,66 @@
+package gocsp
+
+import (
+	"bytes"
+	"crypto"
+	"math/big"
+	"testing"
+)
+
+func TestCertIDHashRoundTrip(t *testing.T) {
+	hashes := []crypto.Hash{
+		crypto.SHA1,
+		crypto.SHA256,
+		crypto.SHA384,
+		crypto.SHA512,
+		crypto.SHA3_256,
+		crypto.SHA3_384,
+		crypto.SHA3_512,
+	}
+	for _, hash := range hashes {
+		t.Run(hash.String(), func(t *testing.T) {
+			if !hash.Available() {
+				t.Skip("no implementation linked")
+			}
+			id, err := NewCertID(hash, testIssuer(t), big.NewInt(5))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := &OcspRequest{}
+			r.TBSRequest.RequestList = []request{{ReqCert: id}}
+			der, err := MarshalRequest(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed, err := UnmarshalRequest(der)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := parsed.CertIDs()[0]
+			if !got.Equal(&id) {
+				t.Error("parsed certID differs from the marshaled one")
+			}
+			if h, err := got.Hash(); err != nil || h != hash {
+				t.Errorf("Hash() = %v, %v, want %v", h, err, hash)
+			}
+			debug, err := parsed.DebugJSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if name := oidNames[hashOIDs[hash].String()]; name == "" || !bytes.Contains(debug, []byte(`"`+name+`"`)) {
+				t.Errorf("DebugJSON does not name the hash algorithm: %s", debug)
+			}
+		})
+	}
+}
+
+func TestCertIDHashRejectsWrongDigestSize(t *testing.T) {
+	id, err := NewCertID(crypto.SHA256, testIssuer(t), big.NewInt(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id.NameHash = id.NameHash[:20]
+	if _, err := id.Hash(); err == nil {
+		t.Error("Hash() accepts a SHA-256 certID with a 20-byte NameHash")
+	}
+}
@@ -0,0 +1,64 @@
+package gocsp
+
+import (
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// https://tools.ietf.org/html/rfc6960#appendix-A.1
+
+// MaxHTTPRequestSize is the largest POST body accepted by RequestFromHTTP.
+const MaxHTTPRequestSize = 64 * 1024
+
+// RequestFromHTTP extracts and unmarshals the OCSP request of an HTTP request to a responder.
+//
+// A POST request carries the DER request as its body, which may not be longer than MaxHTTPRequestSize.
+// A GET request carries it in its path, base64 encoded and then URL escaped. The whole path, without its leading
+// slash, is taken as the request, so a responder served under a prefix should be wrapped with http.StripPrefix.
+//
+// Any failure, including an unsupported HTTP method, returns a *RequestError whose Status is MalformedRequest.
+func RequestFromHTTP(req *http.Request) (*OcspRequest, error) {
+	malformed := func(reason string) error {
+		return &RequestError{Status: MalformedRequest, Reason: reason}
+	}
+	var der []byte
+	switch req.Method {
+	case http.MethodPost:
+		if req.Body == nil {
+			return nil, malformed("empty HTTP body")
+		}
+		body, err := io.ReadAll(io.LimitReader(req.Body, MaxHTTPRequestSize+1))
+		if err != nil {
+			return nil, malformed("reading HTTP body: " + err.Error())
+		}
+		if len(body) > MaxHTTPRequestSize {
+			return nil, malformed("HTTP body is longer than " + strconv.Itoa(MaxHTTPRequestSize) + " bytes")
+		}
+		der = body
+	case http.MethodGet:
+		// PathUnescape, unlike QueryUnescape, keeps a literal '+' of the base64 alphabet.
+		encoded, err := url.PathUnescape(strings.TrimPrefix(req.URL.EscapedPath(), "/"))
+		if err != nil {
+			return nil, malformed("unescaping HTTP path: " + err.Error())
+		}
+		der, err = base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			// Some clients drop the padding.
+			der, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(encoded, "="))
+		}
+		if err != nil {
+			return nil, malformed("decoding HTTP path: " + err.Error())
+		}
+	default:
+		return nil, malformed("unsupported HTTP method " + req.Method)
+	}
+	ocspRequest, err := UnmarshalRequest(der)
+	if err != nil {
+		return nil, malformed(err.Error())
+	}
+	return ocspRequest, nil
+}
@@ -0,0 +1,62 @@
+package gocsp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestRequestFromHTTP(t *testing.T) {
+	der := readTestFile(t, "req-sha1.der")
+	encoded := base64.StdEncoding.EncodeToString(der)
+	// The vector was picked so that its base64 form has a '+', a '/' and padding.
+	if !strings.Contains(encoded, "+") || !strings.Contains(encoded, "/") || !strings.HasSuffix(encoded, "=") {
+		t.Fatalf("base64 of req-sha1.der is %s, want '+', '/' and padding", encoded)
+	}
+	tests := []struct {
+		name string
+		req  *http.Request
+	}{
+		{"POST", httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(der))},
+		{"GET", httptest.NewRequest(http.MethodGet, "/"+encoded, nil)},
+		{"GET escaped", httptest.NewRequest(http.MethodGet, "/"+url.QueryEscape(encoded), nil)},
+		{"GET without padding", httptest.NewRequest(http.MethodGet, "/"+strings.TrimRight(encoded, "="), nil)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := RequestFromHTTP(tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if serial := r.CertIDs()[0].SerialNumber; serial.Int64() != 5 {
+				t.Errorf("serial number = %v, want 5", serial)
+			}
+		})
+	}
+}
+
+func TestRequestFromHTTPMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *http.Request
+	}{
+		{"POST over the size limit", httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(make([]byte, MaxHTTPRequestSize+1)))},
+		{"POST not a request", httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello"))},
+		{"GET not base64", httptest.NewRequest(http.MethodGet, "/%21%21", nil)},
+		{"PUT", httptest.NewRequest(http.MethodPut, "/", nil)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := RequestFromHTTP(tt.req)
+			var requestErr *RequestError
+			if !errors.As(err, &requestErr) || requestErr.Status != MalformedRequest {
+				t.Fatalf("RequestFromHTTP = %v, want a malformedRequest *RequestError", err)
+			}
+		})
+	}
+}
@@ -1,13 +1,211 @@
 package gocsp
 
 import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
 	"math/big"
 )
 
-type certID struct {
+// Version1 is the only version of the OCSP syntax, v1(0) in RFC 6960.
+const Version1 = 0
+
+type CertID struct {
 	HashAlgorithm pkix.AlgorithmIdentifier
 	NameHash      []byte
 	IssuerKeyHash []byte
 	SerialNumber  *big.Int
 }
+
+// NewCertID computes the certID of the certificate with the given serial number issued by issuer.
+//
+// NameHash is computed over the DER of the issuer's subject and IssuerKeyHash over the issuer's
+// subjectPublicKey BIT STRING value. The hash algorithm is encoded with NULL parameters.
+func NewCertID(hash crypto.Hash, issuer *x509.Certificate, serial *big.Int) (CertID, error) {
+	return CertIDFromSPKI(hash, issuer.RawSubject, issuer.RawSubjectPublicKeyInfo, serial)
+}
+
+// CertIDFromSPKI is like NewCertID, but takes the issuer as the DER of its subject Name and of its
+// SubjectPublicKeyInfo, for callers that do not have the parsed issuer certificate.
+//
+// An error is returned if either of them does not parse, or if serial is nil.
+func CertIDFromSPKI(hash crypto.Hash, issuerSubject, issuerSPKI []byte, serial *big.Int) (CertID, error) {
+	if serial == nil {
+		return CertID{}, errors.New("missing serial number for OCSP certID")
+	}
+	hashAlgorithm, err := hashAlgorithmIdentifier(hash)
+	if err != nil {
+		return CertID{}, err
+	}
+	var name pkix.RDNSequence
+	rest, err := asn1.Unmarshal(issuerSubject, &name)
+	if err != nil {
+		return CertID{}, err
+	}
+	if len(rest) > 0 {
+		return CertID{}, errors.New("trailing data in issuer subject")
+	}
+	keyHash, err := spkiKeyHash(hash, issuerSPKI)
+	if err != nil {
+		return CertID{}, err
+	}
+	h := hash.New()
+	h.Write(issuerSubject)
+	return CertID{
+		HashAlgorithm: hashAlgorithm,
+		NameHash:      h.Sum(nil),
+		IssuerKeyHash: keyHash,
+		SerialNumber:  new(big.Int).Set(serial),
+	}, nil
+}
+
+// issuerKeyHash hashes the public key of issuer as required for the IssuerKeyHash of a certID
+// and for a ResponderID by key.
+func issuerKeyHash(hash crypto.Hash, issuer *x509.Certificate) ([]byte, error) {
+	return spkiKeyHash(hash, issuer.RawSubjectPublicKeyInfo)
+}
+
+// spkiKeyHash hashes the public key of a DER SubjectPublicKeyInfo.
+//
+// Only the value of the subjectPublicKey BIT STRING is hashed, without its tag, length and unused-bits byte,
+// and not the whole SubjectPublicKeyInfo. This matches the key hash computed by OpenSSL.
+func spkiKeyHash(hash crypto.Hash, spki []byte) ([]byte, error) {
+	if !hash.Available() {
+		return nil, errUnsupportedHash
+	}
+	publicKey, err := subjectPublicKey(spki)
+	if err != nil {
+		return nil, err
+	}
+	h := hash.New()
+	h.Write(publicKey)
+	return h.Sum(nil), nil
+}
+
+// Hash returns the hash algorithm of the certID.
+//
+// An error is returned if the algorithm is not supported, or if NameHash or IssuerKeyHash do not have its digest size.
+// The hash implementation does not need to be available, so a certID using SHA-3 can be checked and matched without it.
+func (id *CertID) Hash() (crypto.Hash, error) {
+	hash := hashFromOID(id.HashAlgorithm.Algorithm)
+	if hash == 0 {
+		return 0, errUnsupportedHash
+	}
+	if len(id.NameHash) != hash.Size() || len(id.IssuerKeyHash) != hash.Size() {
+		return 0, errors.New("OCSP certID hash does not match the digest size of its algorithm")
+	}
+	return hash, nil
+}
+
+// clone returns a deep copy of id, so that it can be echoed without sharing memory with its source.
+func (id *CertID) clone() CertID {
+	c := CertID{
+		HashAlgorithm: pkix.AlgorithmIdentifier{
+			Algorithm: append(asn1.ObjectIdentifier(nil), id.HashAlgorithm.Algorithm...),
+			Parameters: asn1.RawValue{
+				Class:      id.HashAlgorithm.Parameters.Class,
+				Tag:        id.HashAlgorithm.Parameters.Tag,
+				IsCompound: id.HashAlgorithm.Parameters.IsCompound,
+				Bytes:      append([]byte(nil), id.HashAlgorithm.Parameters.Bytes...),
+				FullBytes:  append([]byte(nil), id.HashAlgorithm.Parameters.FullBytes...),
+			},
+		},
+		NameHash:      append([]byte(nil), id.NameHash...),
+		IssuerKeyHash: append([]byte(nil), id.IssuerKeyHash...),
+	}
+	if id.SerialNumber != nil {
+		c.SerialNumber = new(big.Int).Set(id.SerialNumber)
+	}
+	return c
+}
+
+// canonicalize rewrites the hash algorithm of id into its canonical encoding with NULL parameters,
+// if it is a supported hash.
+//
+// The other fields need no normalization: the hashes always encode as plain OCTET STRINGs and
+// encoding/asn1 always encodes the serial number as a minimal INTEGER.
+func (id *CertID) canonicalize() {
+	hash := hashFromOID(id.HashAlgorithm.Algorithm)
+	if hash == 0 {
+		return
+	}
+	id.HashAlgorithm, _ = hashAlgorithmIdentifier(hash)
+}
+
+// Equal reports whether id and other identify the same certificate with the
+// same hash algorithm, hashes and serial number.
+func (id *CertID) Equal(other *CertID) bool {
+	if !id.HashAlgorithm.Algorithm.Equal(other.HashAlgorithm.Algorithm) {
+		return false
+	}
+	if !bytes.Equal(rawValueBytes(id.HashAlgorithm.Parameters), rawValueBytes(other.HashAlgorithm.Parameters)) {
+		return false
+	}
+	if !bytes.Equal(id.NameHash, other.NameHash) || !bytes.Equal(id.IssuerKeyHash, other.IssuerKeyHash) {
+		return false
+	}
+	if id.SerialNumber == nil || other.SerialNumber == nil {
+		return id.SerialNumber == other.SerialNumber
+	}
+	return id.SerialNumber.Cmp(other.SerialNumber) == 0
+}
+
+// rawValueBytes returns the DER encoding of rv, whether it was parsed or built
+// by hand.
+func rawValueBytes(rv asn1.RawValue) []byte {
+	if len(rv.FullBytes) > 0 {
+		return rv.FullBytes
+	}
+	b, err := asn1.Marshal(rv)
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// subjectPublicKey returns the value of the subjectPublicKey BIT STRING of a DER SubjectPublicKeyInfo.
+func subjectPublicKey(spki []byte) ([]byte, error) {
+	var info struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	rest, err := asn1.Unmarshal(spki, &info)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("trailing data in subject public key info")
+	}
+	return info.PublicKey.RightAlign(), nil
+}
+
+// setExtension replaces the extension with the same OID as ext in extList, keeping its position,
+// or appends ext if there is none.
+func setExtension(extList []pkix.Extension, ext pkix.Extension) []pkix.Extension {
+	for i := range extList {
+		if extList[i].Id.Equal(ext.Id) {
+			extList[i] = ext
+			return extList
+		}
+	}
+	return append(extList, ext)
+}
+
+// nonceValue returns the nonce carried by a nonce extension value.
+//
+// RFC 8954 wraps the nonce in an OCTET STRING, as OcspRequest.SetNonce does, but many implementations store
+// the raw bytes. A value that is exactly one DER OCTET STRING is unwrapped, anything else is returned as is.
+func nonceValue(value []byte) []byte {
+	if len(value) == 0 || value[0] != asn1.TagOctetString {
+		return value
+	}
+	var nonce []byte
+	rest, err := asn1.Unmarshal(value, &nonce)
+	if err != nil || len(rest) > 0 {
+		return value
+	}
+	return nonce
+}
@@ -0,0 +1,147 @@
+package gocsp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// The files in testdata were produced with OpenSSL 3.0 for the certificate with serial number 5
+// issued by testdata/ca.pem, a self-signed P-256 CA. The tests using them describe the ones that
+// were rewritten and signed again.
+
+func readTestFile(t testing.TB, name string) []byte {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func testIssuer(t testing.TB) *x509.Certificate {
+	t.Helper()
+	block, _ := pem.Decode(readTestFile(t, "ca.pem"))
+	if block == nil {
+		t.Fatal("no PEM block in testdata/ca.pem")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestNonceValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value []byte
+		want  []byte
+	}{
+		{"raw", []byte{0x01, 0x02, 0x03}, []byte{0x01, 0x02, 0x03}},
+		{"wrapped", []byte{0x04, 0x03, 0x01, 0x02, 0x03}, []byte{0x01, 0x02, 0x03}},
+		{"raw with an OCTET STRING tag and a wrong length", []byte{0x04, 0x05, 0xaa}, []byte{0x04, 0x05, 0xaa}},
+		{"raw with an OCTET STRING tag and trailing bytes", []byte{0x04, 0x01, 0xaa, 0xbb}, []byte{0x04, 0x01, 0xaa, 0xbb}},
+		{"empty", []byte{}, []byte{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nonceValue(tt.value); !bytes.Equal(got, tt.want) {
+				t.Errorf("nonceValue(%x) = %x, want %x", tt.value, got, tt.want)
+			}
+			r := &OcspRequest{}
+			r.TBSRequest.ExtensionList = []pkix.Extension{{Id: OidOcspNonce, Value: tt.value}}
+			if got := r.Nonce(); !bytes.Equal(got, tt.want) {
+				t.Errorf("Nonce() = %x, want %x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetNonceWrapsValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		nonce []byte
+		value []byte
+	}{
+		{"nonce", []byte{0x01, 0x02, 0x03}, []byte{0x04, 0x03, 0x01, 0x02, 0x03}},
+		{"nonce that is itself an OCTET STRING", []byte{0x04, 0x01, 0xaa}, []byte{0x04, 0x03, 0x04, 0x01, 0xaa}},
+		{"nonce of the maximum length", bytes.Repeat([]byte{0xab}, 32), append([]byte{0x04, 0x20}, bytes.Repeat([]byte{0xab}, 32)...)},
+	}
+	id, err := NewCertID(crypto.SHA1, testIssuer(t), big.NewInt(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &OcspRequest{}
+			r.TBSRequest.RequestList = []request{{ReqCert: id}}
+			r.SetNonce(tt.nonce)
+			if got := r.TBSRequest.ExtensionList[0].Value; !bytes.Equal(got, tt.value) {
+				t.Errorf("extension value = %x, want %x", got, tt.value)
+			}
+			if got := r.Nonce(); !bytes.Equal(got, tt.nonce) {
+				t.Errorf("Nonce() = %x, want %x", got, tt.nonce)
+			}
+			// The length bounds apply to the unwrapped nonce.
+			if err := ValidateRequest(r); err != nil {
+				t.Errorf("ValidateRequest: %v", err)
+			}
+		})
+	}
+}
+
+func TestIssuerKeyHashMatchesOpenSSL(t *testing.T) {
+	issuer := testIssuer(t)
+	tests := []struct {
+		name string
+		hash crypto.Hash
+	}{
+		{"req-sha1.der", crypto.SHA1},
+		{"req-sha256.der", crypto.SHA256},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := UnmarshalRequest(readTestFile(t, tt.name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := r.CertIDs()[0]
+			keyHash, err := issuerKeyHash(tt.hash, issuer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(keyHash, want.IssuerKeyHash) {
+				t.Errorf("issuerKeyHash = %x, OpenSSL computed %x", keyHash, want.IssuerKeyHash)
+			}
+			h := tt.hash.New()
+			h.Write(issuer.RawSubjectPublicKeyInfo)
+			if bytes.Equal(h.Sum(nil), want.IssuerKeyHash) {
+				t.Error("OpenSSL hashed the whole SubjectPublicKeyInfo")
+			}
+			id, err := NewCertID(tt.hash, issuer, big.NewInt(5))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(id.NameHash, want.NameHash) {
+				t.Errorf("NameHash = %x, OpenSSL computed %x", id.NameHash, want.NameHash)
+			}
+		})
+	}
+}
+
+func TestCertIDRejectsNilSerial(t *testing.T) {
+	issuer := testIssuer(t)
+	if _, err := NewCertID(crypto.SHA1, issuer, nil); err == nil {
+		t.Error("NewCertID accepts a nil serial number")
+	}
+	if _, err := CertIDFromSPKI(crypto.SHA1, issuer.RawSubject, issuer.RawSubjectPublicKeyInfo, nil); err == nil {
+		t.Error("CertIDFromSPKI accepts a nil serial number")
+	}
+}
@@ -1,12 +1,19 @@
 package gocsp
 
 import (
+	"bytes"
+	"crypto"
 	"crypto/x509/pkix"
 	"encoding/asn1"
 	"errors"
 )
 
 var OidOcspNonce = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 2}
+var OidOcspAcceptableResponses = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 4}
+
+// ErrNonceConflict is returned by AggregateNonce when the aggregated requests
+// carry different nonces, so no single nonce can be echoed.
+var ErrNonceConflict = errors.New("conflicting nonces in aggregated OCSP requests")
 
 // https://tools.ietf.org/html/rfc6960#section-4.1.1
 // https://tools.ietf.org/html/rfc6960#appendix-B.2
@@ -23,14 +30,18 @@ type signature struct {
 }
 
 type tbsRequest struct {
-	Version       int              `asn1:"default:0,explicit,tag:0,optional"`
-	RequestorName pkix.RDNSequence `asn1:"explicit,tag:1,optional"`
+	// Raw holds the DER encoding of a parsed tbsRequest, which is the data covered by the signature.
+	// It is only used to check the signature: MarshalRequest always encodes the other fields.
+	Raw     asn1.RawContent
+	Version int `asn1:"default:0,explicit,tag:0,optional"`
+	// RequestorName holds the whole [1] element, whose content is a GeneralName.
+	RequestorName asn1.RawValue `asn1:"explicit,tag:1,optional"`
 	RequestList   []request
 	ExtensionList []pkix.Extension `asn1:"explicit,tag:2,optional"`
 }
 
 type request struct {
-	ReqCert                 certID
+	ReqCert                 CertID
 	SingleRequestExtensions []pkix.Extension `asn1:"explicit,tag:0,optional"`
 }
 
@@ -55,11 +66,25 @@ func UnmarshalRequest(request []byte) (*OcspRequest, error) {
 
 // MarshalRequest marshals the given ocspRequest into its ASN.1 DER encoding.
 //
+// The certIDs of an unsigned request are encoded canonically, so that logically identical requests always produce
+// identical bytes; ocspRequest itself is not modified. The certIDs of a signed request are encoded as they are,
+// since canonicalizing them would invalidate the signature.
+// The tbsRequest is encoded from its fields, never from the bytes captured when it was parsed, so a parsed request
+// may not be re-encoded exactly as received; its signature is still checked over the received bytes.
+//
 // ocspRequest: The OCSP request to be marshaled.
 // []byte: The marshaled OCSP request in ASN.1 DER encoding.
 // error: An error if the marshaling process fails.
 func MarshalRequest(ocspRequest *OcspRequest) ([]byte, error) {
-	b, err := asn1.Marshal(*ocspRequest)
+	encoded := *ocspRequest
+	encoded.TBSRequest.Raw = nil
+	if !ocspRequest.IsSigned() {
+		encoded.TBSRequest.RequestList = append([]request(nil), ocspRequest.TBSRequest.RequestList...)
+		for i := range encoded.TBSRequest.RequestList {
+			encoded.TBSRequest.RequestList[i].ReqCert.canonicalize()
+		}
+	}
+	b, err := asn1.Marshal(encoded)
 	return b, err
 }
 
@@ -67,11 +92,204 @@ func MarshalRequest(ocspRequest *OcspRequest) ([]byte, error) {
 //
 // No parameters.
 // Returns a byte slice with the nonce value from request, or nil for no nonce.
+// A nonce wrapped in an OCTET STRING is returned unwrapped, the same as a raw nonce.
 func (r *OcspRequest) Nonce() []byte {
 	for _, extension := range r.TBSRequest.ExtensionList {
 		if extension.Id.Equal(OidOcspNonce) {
-			return extension.Value
+			return nonceValue(extension.Value)
+		}
+	}
+	return nil
+}
+
+// Version returns the version declared by the request, where Version1 is 0.
+//
+// An absent version is parsed as the default Version1. Any other value is returned as encoded,
+// so that a responder can answer malformedRequest instead of failing to parse the request.
+func (r *OcspRequest) Version() int {
+	return r.TBSRequest.Version
+}
+
+// RequestorNameString renders the optional requestor name of the request for logging.
+//
+// The requestor name is a GeneralName. A directoryName is rendered as its distinguished name, and an rfc822Name,
+// dNSName or uniformResourceIdentifier as its string value.
+// It returns false if the request has no requestor name or if it is of another GeneralName type.
+func (r *OcspRequest) RequestorNameString() (string, bool) {
+	if len(r.TBSRequest.RequestorName.Bytes) == 0 {
+		return "", false
+	}
+	var generalName asn1.RawValue
+	if _, err := asn1.Unmarshal(r.TBSRequest.RequestorName.Bytes, &generalName); err != nil {
+		return "", false
+	}
+	if generalName.Class != asn1.ClassContextSpecific {
+		return "", false
+	}
+	switch generalName.Tag {
+	case 1, 2, 6:
+		// rfc822Name, dNSName and uniformResourceIdentifier are IMPLICIT IA5String.
+		return string(generalName.Bytes), true
+	case 4:
+		// directoryName is EXPLICIT, as Name is a CHOICE.
+		var name pkix.RDNSequence
+		rest, err := asn1.Unmarshal(generalName.Bytes, &name)
+		if err != nil || len(rest) > 0 {
+			return "", false
+		}
+		return name.String(), true
+	}
+	return "", false
+}
+
+// CertIDs returns the certIDs of all single requests, in request order.
+func (r *OcspRequest) CertIDs() []CertID {
+	certIDs := make([]CertID, 0, len(r.TBSRequest.RequestList))
+	for _, singleRequest := range r.TBSRequest.RequestList {
+		certIDs = append(certIDs, singleRequest.ReqCert)
+	}
+	return certIDs
+}
+
+// TBSRequestBytes returns the tbsRequest bytes captured when the request was unmarshaled,
+// which are the bytes covered by its signature.
+//
+// It returns nil for a request that was built or modified rather than parsed.
+func (r *OcspRequest) TBSRequestBytes() []byte {
+	return r.TBSRequest.Raw
+}
+
+// IsSigned reports whether the request carries the optional signature, that is both a signature algorithm
+// and a signature value.
+//
+// A responder requiring signed requests should answer SigRequired when it returns false.
+func (r *OcspRequest) IsSigned() bool {
+	return len(r.Signature.SignatureAlgorithm.Algorithm) > 0 && r.Signature.Signature.BitLength > 0
+}
+
+// VerifySignature verifies the signature of a signed request with the public key of the requestor,
+// usually taken from a certificate embedded in the signature.
+//
+// The signature is checked over the tbsRequest bytes as received, not over a re-encoding of the parsed fields,
+// since a re-encoding is not guaranteed to reproduce the signed bytes.
+// Whether the requestor is trusted is left to the caller.
+func (r *OcspRequest) VerifySignature(publicKey crypto.PublicKey) error {
+	if !r.IsSigned() {
+		return errors.New("OCSP request is not signed")
+	}
+	tbs := r.TBSRequestBytes()
+	if tbs == nil {
+		var err error
+		tbs, err = asn1.Marshal(r.TBSRequest)
+		if err != nil {
+			return err
 		}
 	}
+	return checkSignature(r.Signature.SignatureAlgorithm, tbs, r.Signature.Signature, publicKey)
+}
+
+// ClearSignature removes the optional signature of the request, including its certificates.
+//
+// A request marshaled afterwards has no signature field at all, so it can be modified and signed again.
+func (r *OcspRequest) ClearSignature() {
+	r.Signature = signature{}
+}
+
+// SetNonce sets the nonce extension of the request, replacing an existing nonce.
+//
+// nonce: The nonce value, wrapped in an OCTET STRING as the extension value as required by RFC 8954.
+func (r *OcspRequest) SetNonce(nonce []byte) {
+	// Marshaling a byte slice cannot fail.
+	value, _ := asn1.Marshal(nonce)
+	r.TBSRequest.ExtensionList = setExtension(r.TBSRequest.ExtensionList, pkix.Extension{
+		Id:    OidOcspNonce,
+		Value: value,
+	})
+	r.TBSRequest.Raw = nil
+}
+
+// SetAcceptableResponses sets the acceptable response types extension of the request, replacing an existing one.
+//
+// responseTypes: The OIDs of the response types the client understands, usually OidOcspBasicResponse.
+// error: An error if the extension value cannot be encoded.
+func (r *OcspRequest) SetAcceptableResponses(responseTypes []asn1.ObjectIdentifier) error {
+	value, err := asn1.Marshal(responseTypes)
+	if err != nil {
+		return err
+	}
+	r.TBSRequest.ExtensionList = setExtension(r.TBSRequest.ExtensionList, pkix.Extension{
+		Id:    OidOcspAcceptableResponses,
+		Value: value,
+	})
+	r.TBSRequest.Raw = nil
 	return nil
 }
+
+// AcceptableResponses returns the response types listed in the acceptable response types extension.
+//
+// It returns nil and no error if the request has no such extension.
+func (r *OcspRequest) AcceptableResponses() ([]asn1.ObjectIdentifier, error) {
+	for _, extension := range r.TBSRequest.ExtensionList {
+		if extension.Id.Equal(OidOcspAcceptableResponses) {
+			var responseTypes []asn1.ObjectIdentifier
+			rest, err := asn1.Unmarshal(extension.Value, &responseTypes)
+			if err != nil {
+				return nil, err
+			}
+			if len(rest) > 0 {
+				return nil, errors.New("trailing data in acceptable responses extension")
+			}
+			return responseTypes, nil
+		}
+	}
+	return nil, nil
+}
+
+// AggregateRequests collects the certIDs of all given requests into one list,
+// so that a single response can answer them together.
+//
+// Duplicate certIDs are only returned once, in the order they were first seen.
+// It returns an error if the requests do not contain any certID.
+// Nonces are not aggregated here; use AggregateNonce to decide whether one can be echoed.
+func AggregateRequests(reqs []*OcspRequest) ([]CertID, error) {
+	var certIDs []CertID
+	for _, r := range reqs {
+		for _, singleRequest := range r.TBSRequest.RequestList {
+			duplicate := false
+			for i := range certIDs {
+				if certIDs[i].Equal(&singleRequest.ReqCert) {
+					duplicate = true
+					break
+				}
+			}
+			if !duplicate {
+				certIDs = append(certIDs, singleRequest.ReqCert)
+			}
+		}
+	}
+	if len(certIDs) == 0 {
+		return nil, errors.New("no certID in aggregated OCSP requests")
+	}
+	return certIDs, nil
+}
+
+// AggregateNonce returns the nonce to echo in a response aggregating reqs.
+//
+// Requests without a nonce are ignored. If all remaining requests carry the same nonce, it is returned.
+// If they carry different nonces, it returns nil and ErrNonceConflict; the caller should then drop the
+// nonce from the aggregated response, since a response can only echo one.
+func AggregateNonce(reqs []*OcspRequest) ([]byte, error) {
+	var nonce []byte
+	for _, r := range reqs {
+		n := r.Nonce()
+		if n == nil {
+			continue
+		}
+		if nonce == nil {
+			nonce = n
+		} else if !bytes.Equal(nonce, n) {
+			return nil, ErrNonceConflict
+		}
+	}
+	return nonce, nil
+}
@@ -0,0 +1,319 @@
+package gocsp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+)
+
+// signTestRequest returns a request whose tbsRequest is the DER encoding of tbs, signed with key.
+func signTestRequest(t testing.TB, tbs interface{}, key *ecdsa.PrivateKey) []byte {
+	t.Helper()
+	tbsDER, err := asn1.Marshal(tbs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256(tbsDER)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := asn1.Marshal(struct {
+		TBSRequest asn1.RawValue
+		Signature  signature `asn1:"explicit,tag:0"`
+	}{
+		TBSRequest: asn1.RawValue{FullBytes: tbsDER},
+		Signature: signature{
+			SignatureAlgorithm: signingAlgorithmFor(t, key.Public()),
+			Signature:          asn1.BitString{Bytes: sig, BitLength: 8 * len(sig)},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func TestVerifySignatureOverReceivedBytes(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := NewCertID(crypto.SHA1, testIssuer(t), big.NewInt(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An explicitly encoded default version is not DER, so re-encoding the parsed request drops it.
+	tbs := struct {
+		Version     int `asn1:"explicit,tag:0"`
+		RequestList []request
+	}{Version1, []request{{ReqCert: id}}}
+	der := signTestRequest(t, tbs, key)
+	tbsDER, err := asn1.Marshal(tbs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := UnmarshalRequest(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	remarshaled, err := MarshalRequest(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(remarshaled, der) {
+		t.Fatal("re-marshaled request is identical to the received one")
+	}
+	if !bytes.Equal(r.TBSRequestBytes(), tbsDER) {
+		t.Error("TBSRequestBytes does not return the received tbsRequest")
+	}
+	if err := r.VerifySignature(key.Public()); err != nil {
+		t.Errorf("VerifySignature: %v", err)
+	}
+}
+
+func TestRequestorNameFromOpenSSL(t *testing.T) {
+	// Generated with: openssl ocsp -issuer ca.pem -serial 5 -no_nonce -signer ca.pem -signkey ca.key -reqout req.der
+	der := readTestFile(t, "req-signed.der")
+	r, err := UnmarshalRequest(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, ok := r.RequestorNameString(); !ok || name != "CN=gocsp Test CA" {
+		t.Errorf("RequestorNameString = %q, %v, want the directoryName CN=gocsp Test CA", name, ok)
+	}
+	if err := r.VerifySignature(testIssuer(t).PublicKey); err != nil {
+		t.Errorf("VerifySignature: %v", err)
+	}
+	if remarshaled, err := MarshalRequest(r); err != nil || !bytes.Equal(remarshaled, der) {
+		t.Errorf("re-marshaling the parsed request gives different bytes, err = %v", err)
+	}
+}
+
+func TestRequestorNameRoundTrip(t *testing.T) {
+	directoryName, err := asn1.Marshal(pkix.Name{CommonName: "requestor"}.ToRDNSequence())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name        string
+		generalName asn1.RawValue
+		want        string
+		wantOK      bool
+	}{
+		{"directoryName", asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 4, IsCompound: true, Bytes: directoryName}, "CN=requestor", true},
+		{"dNSName", asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 2, Bytes: []byte("ocsp.example.com")}, "ocsp.example.com", true},
+		// Not a GeneralName, whose alternatives are all context-specific.
+		{"universal UTF8String", asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagUTF8String, Bytes: []byte("requestor")}, "", false},
+	}
+	id, err := NewCertID(crypto.SHA1, testIssuer(t), big.NewInt(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generalName, err := asn1.Marshal(tt.generalName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := &OcspRequest{}
+			r.TBSRequest.RequestorName = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 1, IsCompound: true, Bytes: generalName}
+			r.TBSRequest.RequestList = []request{{ReqCert: id}}
+			der, err := MarshalRequest(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := UnmarshalRequest(der)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got.TBSRequest.RequestorName.Bytes, generalName) {
+				t.Errorf("requestor name = %x, want %x", got.TBSRequest.RequestorName.Bytes, generalName)
+			}
+			if name, ok := got.RequestorNameString(); name != tt.want || ok != tt.wantOK {
+				t.Errorf("RequestorNameString = %q, %v, want %q, %v", name, ok, tt.want, tt.wantOK)
+			}
+			if again, err := MarshalRequest(got); err != nil || !bytes.Equal(again, der) {
+				t.Errorf("re-marshaling the parsed request gives different bytes, err = %v", err)
+			}
+		})
+	}
+}
+
+func TestMarshalRequestEncodesChangedFields(t *testing.T) {
+	r, err := UnmarshalRequest(readTestFile(t, "req-sha1.der"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.TBSRequest.RequestList[0].ReqCert.SerialNumber = big.NewInt(6)
+	der, err := MarshalRequest(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := UnmarshalRequest(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if serial := got.CertIDs()[0].SerialNumber; serial.Cmp(big.NewInt(6)) != 0 {
+		t.Errorf("serial number = %v, want 6", serial)
+	}
+}
+
+func signingAlgorithmFor(t testing.TB, pub crypto.PublicKey) pkix.AlgorithmIdentifier {
+	t.Helper()
+	alg, _, err := signingAlgorithm(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return alg
+}
+
+func TestMarshalSignedRequestKeepsCertIDs(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := NewCertID(crypto.SHA256, testIssuer(t), big.NewInt(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Absent hash parameters are valid, but not the canonical NULL.
+	id.HashAlgorithm.Parameters = asn1.RawValue{}
+	der := signTestRequest(t, struct{ RequestList []request }{[]request{{ReqCert: id}}}, key)
+
+	r, err := UnmarshalRequest(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	remarshaled, err := MarshalRequest(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(remarshaled, der) {
+		t.Error("re-marshaling a signed request changes its bytes")
+	}
+	again, err := UnmarshalRequest(remarshaled)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := again.VerifySignature(key.Public()); err != nil {
+		t.Errorf("VerifySignature on the re-marshaled request: %v", err)
+	}
+}
+
+func TestMarshalRequestDoesNotModifyRequest(t *testing.T) {
+	id, err := NewCertID(crypto.SHA256, testIssuer(t), big.NewInt(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id.HashAlgorithm.Parameters = asn1.RawValue{}
+	r := &OcspRequest{}
+	r.TBSRequest.RequestList = []request{{ReqCert: id}}
+	if _, err := MarshalRequest(r); err != nil {
+		t.Fatal(err)
+	}
+	if params := r.TBSRequest.RequestList[0].ReqCert.HashAlgorithm.Parameters; len(params.FullBytes) != 0 || params.Tag != 0 {
+		t.Errorf("MarshalRequest changed the hash parameters of the caller's certID to %+v", params)
+	}
+}
+
+func TestMarshalRequestIsCanonical(t *testing.T) {
+	build := func(params asn1.RawValue) []byte {
+		t.Helper()
+		id, err := NewCertID(crypto.SHA256, testIssuer(t), big.NewInt(5))
+		if err != nil {
+			t.Fatal(err)
+		}
+		id.HashAlgorithm.Parameters = params
+		r := &OcspRequest{}
+		r.TBSRequest.RequestList = []request{{ReqCert: id}}
+		der, err := MarshalRequest(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return der
+	}
+	withNull := build(asn1.NullRawValue)
+	if again := build(asn1.NullRawValue); !bytes.Equal(again, withNull) {
+		t.Error("two builds of the same request differ")
+	}
+	if absent := build(asn1.RawValue{}); !bytes.Equal(absent, withNull) {
+		t.Error("a certID without hash parameters is not encoded with NULL parameters")
+	}
+}
+
+func TestRequestNonceAndAcceptableResponsesRoundTrip(t *testing.T) {
+	id, err := NewCertID(crypto.SHA1, testIssuer(t), big.NewInt(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := []byte("0123456789abcdef")
+	r := &OcspRequest{}
+	r.TBSRequest.RequestList = []request{{ReqCert: id}}
+	r.SetNonce(nonce)
+	if err := r.SetAcceptableResponses([]asn1.ObjectIdentifier{OidOcspBasicResponse}); err != nil {
+		t.Fatal(err)
+	}
+	der, err := MarshalRequest(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := UnmarshalRequest(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	extensions := got.TBSRequest.ExtensionList
+	if len(extensions) != 2 || !extensions[0].Id.Equal(OidOcspNonce) || !extensions[1].Id.Equal(OidOcspAcceptableResponses) {
+		t.Fatalf("request extensions = %v, want the nonce then the acceptable responses", extensions)
+	}
+	if !bytes.Equal(got.Nonce(), nonce) {
+		t.Errorf("Nonce = %x, want %x", got.Nonce(), nonce)
+	}
+	acceptable, err := got.AcceptableResponses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(acceptable) != 1 || !acceptable[0].Equal(OidOcspBasicResponse) {
+		t.Errorf("AcceptableResponses = %v, want [%v]", acceptable, OidOcspBasicResponse)
+	}
+	if again, err := MarshalRequest(got); err != nil || !bytes.Equal(again, der) {
+		t.Errorf("re-marshaling the parsed request gives different bytes, err = %v", err)
+	}
+}
+
+func TestMarshalRequestMatchesOpenSSL(t *testing.T) {
+	// Generated with: openssl ocsp [-sha256] -issuer ca.pem -serial 5 -no_nonce -reqout req.der
+	tests := []struct {
+		name string
+		hash crypto.Hash
+	}{
+		{"req-sha1.der", crypto.SHA1},
+		{"req-sha256.der", crypto.SHA256},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := NewCertID(tt.hash, testIssuer(t), big.NewInt(5))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := &OcspRequest{}
+			r.TBSRequest.RequestList = []request{{ReqCert: id}}
+			der, err := MarshalRequest(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := readTestFile(t, tt.name); !bytes.Equal(der, want) {
+				t.Errorf("MarshalRequest = %x, OpenSSL encoded %x", der, want)
+			}
+		})
+	}
+}
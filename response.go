@@ -1,9 +1,16 @@
 package gocsp
 
 import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/asn1"
 	"errors"
+	"math"
+	"math/big"
+	"strconv"
+	"sync"
 	"time"
 )
 
@@ -11,6 +18,19 @@ import (
 // https://tools.ietf.org/html/rfc6960#appendix-B.2
 
 var OidOcspBasicResponse = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 1}
+var OidOcspArchiveCutoff = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 6}
+var OidOcspExtendedRevoke = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 9}
+
+// NoExpiry is returned by TimeToExpiry when a single response has no NextUpdate.
+// Per RFC 6960 section 2.4 this means newer status information is available at any time.
+const NoExpiry = time.Duration(math.MaxInt64)
+
+// ErrNoSingleResponses is returned when marshaling or signing a basic response without any single response.
+// A responder that cannot answer any certID should send an error status such as malformedRequest instead.
+var ErrNoSingleResponses = errors.New("OCSP basic response has no single response")
+
+// ErrUnacceptableResponseType is returned by CheckResponseType for a response type the request did not accept.
+var ErrUnacceptableResponseType = errors.New("OCSP response type is not acceptable to the request")
 
 type OcspResponse struct {
 	// ResponseStatus ENUMERATED {
@@ -26,6 +46,46 @@ type OcspResponse struct {
 	ResponseBytes  responseBytes `asn1:"explicit,tag:0,optional"`
 }
 
+// ResponseStatus is the typed value of OcspResponse.ResponseStatus.
+type ResponseStatus int
+
+const (
+	Successful       ResponseStatus = 0
+	MalformedRequest ResponseStatus = 1
+	InternalError    ResponseStatus = 2
+	TryLater         ResponseStatus = 3
+	SigRequired      ResponseStatus = 5
+	Unauthorized     ResponseStatus = 6
+)
+
+func (s ResponseStatus) String() string {
+	switch s {
+	case Successful:
+		return "successful"
+	case MalformedRequest:
+		return "malformedRequest"
+	case InternalError:
+		return "internalError"
+	case TryLater:
+		return "tryLater"
+	case SigRequired:
+		return "sigRequired"
+	case Unauthorized:
+		return "unauthorized"
+	}
+	return "unknown response status " + strconv.Itoa(int(s))
+}
+
+// ResponseStatusError is returned for an OCSP response whose status is not successful,
+// so that callers can use errors.As to react to it, for example by retrying on TryLater.
+type ResponseStatusError struct {
+	Status ResponseStatus
+}
+
+func (e *ResponseStatusError) Error() string {
+	return "OCSP response status is " + e.Status.String()
+}
+
 type responseBytes struct {
 	ResponseType asn1.ObjectIdentifier
 	Response     []byte
@@ -39,16 +99,21 @@ type BasicResponse struct {
 }
 
 type responseData struct {
+	// Raw holds the DER encoding of a parsed responseData, which is the data covered by the signature.
+	// It is only used to check and export the signature: MarshalBasicResponse always encodes the other fields.
+	Raw asn1.RawContent
+	// Version is 0 both when it is omitted and when it is explicitly encoded as 0, as some responders do.
+	// It is omitted when marshaling 0, so only Raw preserves an explicit encoding for signature checks.
 	Version int `asn1:"default:0,explicit,tag:0,optional"`
 	// ResponderID has to be either Name or KeyHash (SHA-1 hash of responder's public key, excluding the tag and length fields)
 	ResponderID        asn1.RawValue
 	ProducedAt         time.Time `asn1:"generalized"`
-	Responses          []singleResponse
+	Responses          []SingleResponse
 	ResponseExtensions []pkix.Extension `asn1:"explicit,tag:1,optional"`
 }
 
-type singleResponse struct {
-	CertID certID
+type SingleResponse struct {
+	CertID CertID
 	// CertStatus CHOICE {
 	//    good                [0]     IMPLICIT NULL,
 	//    revoked             [1]     IMPLICIT RevokedInfo,
@@ -62,6 +127,48 @@ type singleResponse struct {
 	SingleExtensions []pkix.Extension `asn1:"explicit,tag:1,optional"`
 }
 
+// CertStatus is the status of the certificate in a single response.
+type CertStatus int
+
+const (
+	StatusGood CertStatus = iota
+	StatusRevoked
+	StatusUnknown
+)
+
+func (s CertStatus) String() string {
+	switch s {
+	case StatusGood:
+		return "good"
+	case StatusRevoked:
+		return "revoked"
+	}
+	return "unknown"
+}
+
+// status returns the certificate status, with the same precedence as MarshalBasicResponse:
+// good wins over revoked, and a single response without any status is unknown.
+func (sr *SingleResponse) status() CertStatus {
+	if sr.Good == true {
+		return StatusGood
+	}
+	if !sr.Revoked.IsEmpty() {
+		return StatusRevoked
+	}
+	return StatusUnknown
+}
+
+// ValidityInterval returns the interval in which the single response is valid: from ThisUpdate to NextUpdate.
+//
+// hasEnd is false when the response has no NextUpdate, meaning newer information is always available;
+// end is then the zero time.
+func (sr *SingleResponse) ValidityInterval() (start, end time.Time, hasEnd bool) {
+	if sr.NextUpdate.IsZero() {
+		return sr.ThisUpdate, time.Time{}, false
+	}
+	return sr.ThisUpdate, sr.NextUpdate, true
+}
+
 type RevokedInfo struct {
 	RevocationTime   time.Time       `asn1:"generalized"`
 	RevocationReason asn1.Enumerated `asn1:"explicit,tag:0,optional"`
@@ -87,6 +194,103 @@ func UnmarshalResponse(response []byte) (*OcspResponse, error) {
 	return &ocspResponse, nil
 }
 
+// oidOcspBasicResponseDER is the DER encoding of OidOcspBasicResponse, to recognize it without decoding it.
+var oidOcspBasicResponseDER, _ = asn1.Marshal(OidOcspBasicResponse)
+
+// responseScratch holds the intermediate structures of UnmarshalResponseInto. They are pooled, since passing
+// them to encoding/asn1 would otherwise allocate them on every call.
+type responseScratch struct {
+	envelope struct {
+		ResponseStatus asn1.Enumerated
+		// ResponseBytes holds the whole [0] element, whose content is the responseBytes SEQUENCE.
+		ResponseBytes asn1.RawValue `asn1:"explicit,tag:0,optional"`
+	}
+	responseBytes struct {
+		ResponseType asn1.RawValue
+		Response     asn1.RawValue
+	}
+}
+
+var responseScratchPool = sync.Pool{
+	New: func() interface{} { return new(responseScratch) },
+}
+
+// UnmarshalResponseInto is like UnmarshalResponse, but decodes into dst so that callers parsing many
+// responses can reuse one OcspResponse.
+//
+// The response type and response bytes are copied into the buffers of dst when they are large enough,
+// so that decoding a basic response into a reused dst does not allocate them again. Fields absent from
+// response are reset, so no data of the previously decoded response is left. Results previously decoded
+// into dst are overwritten, so they must not be in use anymore. On error, the content of dst is undefined.
+func UnmarshalResponseInto(response []byte, dst *OcspResponse) error {
+	scratch := responseScratchPool.Get().(*responseScratch)
+	defer func() {
+		// Do not keep response alive through the pool.
+		*scratch = responseScratch{}
+		responseScratchPool.Put(scratch)
+	}()
+	envelope, raw := &scratch.envelope, &scratch.responseBytes
+	rest, err := asn1.Unmarshal(response, envelope)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return errors.New("trailing data in OCSP response")
+	}
+	dst.ResponseStatus = envelope.ResponseStatus
+	if len(envelope.ResponseBytes.FullBytes) == 0 {
+		dst.ResponseBytes = responseBytes{}
+		return nil
+	}
+	rest, err = asn1.Unmarshal(envelope.ResponseBytes.Bytes, raw)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return errors.New("trailing data in OCSP response bytes")
+	}
+	if raw.ResponseType.Class != asn1.ClassUniversal || raw.ResponseType.Tag != asn1.TagOID || raw.ResponseType.IsCompound {
+		return asn1.StructuralError{Msg: "OCSP response type is not an OBJECT IDENTIFIER"}
+	}
+	if raw.Response.Class != asn1.ClassUniversal || raw.Response.Tag != asn1.TagOctetString || raw.Response.IsCompound {
+		return asn1.StructuralError{Msg: "OCSP response is not an OCTET STRING"}
+	}
+	if bytes.Equal(raw.ResponseType.FullBytes, oidOcspBasicResponseDER) {
+		dst.ResponseBytes.ResponseType = append(dst.ResponseBytes.ResponseType[:0], OidOcspBasicResponse...)
+	} else {
+		dst.ResponseBytes.ResponseType = nil
+		if _, err := asn1.Unmarshal(raw.ResponseType.FullBytes, &dst.ResponseBytes.ResponseType); err != nil {
+			return err
+		}
+	}
+	dst.ResponseBytes.Response = append(dst.ResponseBytes.Response[:0], raw.Response.Bytes...)
+	return nil
+}
+
+// CheckResponseType checks that the response type of response is one of the types listed in the
+// acceptable responses extension of the request it answers.
+//
+// Nothing is checked if the request has no such extension or if response carries no response bytes,
+// as for unsuccessful statuses.
+func CheckResponseType(request *OcspRequest, response *OcspResponse) error {
+	if len(response.ResponseBytes.ResponseType) == 0 {
+		return nil
+	}
+	acceptable, err := request.AcceptableResponses()
+	if err != nil {
+		return err
+	}
+	if acceptable == nil {
+		return nil
+	}
+	for _, responseType := range acceptable {
+		if responseType.Equal(response.ResponseBytes.ResponseType) {
+			return nil
+		}
+	}
+	return ErrUnacceptableResponseType
+}
+
 func UnmarshalResponseToBasic(response []byte) (*BasicResponse, error) {
 	var ocspResponse OcspResponse
 	rest, err := asn1.Unmarshal(response, &ocspResponse)
@@ -96,6 +300,9 @@ func UnmarshalResponseToBasic(response []byte) (*BasicResponse, error) {
 	if len(rest) > 0 {
 		return nil, errors.New("trailing data in OCSP response")
 	}
+	if status := ResponseStatus(ocspResponse.ResponseStatus); status != Successful {
+		return nil, &ResponseStatusError{Status: status}
+	}
 	basicResponse, err := UnmarshalBasicResponse(ocspResponse.ResponseBytes.Response)
 	if err != nil {
 		return nil, err
@@ -139,11 +346,27 @@ func MarshalResponseFromBasic(basicResponse *BasicResponse) ([]byte, error) {
 	return r, err
 }
 
+// UnmarshalBasicResponse unmarshals a DER BasicOCSPResponse.
+//
+// A good status encoded as an explicit NULL is accepted, and the signature is still checked over the bytes as received.
+// Such a response cannot be re-encoded with a valid signature, so a caller relaying parsed responses must keep
+// the original bytes rather than pass the response to MarshalBasicResponse.
 func UnmarshalBasicResponse(basicResponse []byte) (*BasicResponse, error) {
 	var basic BasicResponse
 	rest, err := asn1.Unmarshal(basicResponse, &basic)
 	if err != nil {
-		return nil, err
+		// Some encoders send the good status as an explicit NULL, which does not match the IMPLICIT tag.
+		normalized, tbs, ok := normalizeGoodStatus(basicResponse)
+		if !ok {
+			return nil, err
+		}
+		basic = BasicResponse{}
+		rest, err = asn1.Unmarshal(normalized, &basic)
+		if err != nil {
+			return nil, err
+		}
+		// Keep the bytes as received, they are what the signature covers.
+		basic.TBSResponseData.Raw = tbs
 	}
 	if len(rest) > 0 {
 		return nil, errors.New("trailing data in OCSP basic response")
@@ -151,11 +374,112 @@ func UnmarshalBasicResponse(basicResponse []byte) (*BasicResponse, error) {
 	return &basic, nil
 }
 
+// normalizeGoodStatus rewrites every good status encoded as [0] EXPLICIT NULL (A0 02 05 00)
+// into the [0] IMPLICIT NULL (80 00) defined by RFC 6960.
+//
+// It returns the rewritten basic response together with the original responseData bytes,
+// and false if the response has no such status or cannot be walked.
+func normalizeGoodStatus(basicResponse []byte) ([]byte, []byte, bool) {
+	var basic asn1.RawValue
+	if _, err := asn1.Unmarshal(basicResponse, &basic); err != nil {
+		return nil, nil, false
+	}
+	basicElements, err := splitElements(basic.Bytes)
+	if err != nil || len(basicElements) == 0 {
+		return nil, nil, false
+	}
+	tbs := basicElements[0]
+	tbsElements, err := splitElements(tbs.Bytes)
+	if err != nil {
+		return nil, nil, false
+	}
+	changed := false
+	for i, element := range tbsElements {
+		// Responses is the only universal SEQUENCE in responseData.
+		if element.Class != asn1.ClassUniversal || element.Tag != asn1.TagSequence {
+			continue
+		}
+		responses, err := splitElements(element.Bytes)
+		if err != nil {
+			return nil, nil, false
+		}
+		for j, response := range responses {
+			fields, err := splitElements(response.Bytes)
+			if err != nil {
+				return nil, nil, false
+			}
+			if len(fields) < 2 {
+				continue
+			}
+			status := fields[1]
+			if status.Class == asn1.ClassContextSpecific && status.Tag == 0 && status.IsCompound &&
+				bytes.Equal(status.Bytes, asn1.NullBytes) {
+				fields[1] = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, FullBytes: []byte{0x80, 0x00}}
+				responses[j] = joinElements(response, fields)
+				changed = true
+			}
+		}
+		tbsElements[i] = joinElements(element, responses)
+		break
+	}
+	if !changed {
+		return nil, nil, false
+	}
+	basicElements[0] = joinElements(tbs, tbsElements)
+	return joinElements(basic, basicElements).FullBytes, tbs.FullBytes, true
+}
+
+// splitElements parses the content of a constructed DER element into its children.
+func splitElements(content []byte) ([]asn1.RawValue, error) {
+	var elements []asn1.RawValue
+	for len(content) > 0 {
+		var element asn1.RawValue
+		rest, err := asn1.Unmarshal(content, &element)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, element)
+		content = rest
+	}
+	return elements, nil
+}
+
+// joinElements re-encodes the constructed element parent with children as its content.
+func joinElements(parent asn1.RawValue, children []asn1.RawValue) asn1.RawValue {
+	var content []byte
+	for _, child := range children {
+		content = append(content, child.FullBytes...)
+	}
+	joined := asn1.RawValue{Class: parent.Class, Tag: parent.Tag, IsCompound: true, Bytes: content}
+	joined.FullBytes, _ = asn1.Marshal(joined)
+	return joined
+}
+
+// MarshalBasicResponse marshals the basic response into its ASN.1 DER encoding.
+//
+// The responseData is encoded from its fields, never from the bytes captured when it was parsed,
+// so that changes to a parsed response are not lost. The signature is kept as is and has to be
+// renewed with SignBasicResponse after such changes.
+// A parsed response is not guaranteed to be re-encoded as received, for example a good status received as an
+// explicit NULL is encoded as the IMPLICIT NULL of RFC 6960, which invalidates the signature. A caller relaying
+// parsed responses without re-signing them must keep the original bytes.
 func MarshalBasicResponse(basicResponse *BasicResponse) ([]byte, error) {
+	if len(basicResponse.TBSResponseData.Responses) == 0 {
+		return nil, ErrNoSingleResponses
+	}
+	basicResponse.normalizeStatuses()
+	encoded := *basicResponse
+	encoded.TBSResponseData.Raw = nil
+	b, err := asn1.Marshal(encoded)
+	return b, err
+}
+
+// normalizeStatuses leaves exactly one status set in every single response.
+func (basicResponse *BasicResponse) normalizeStatuses() {
 	for i, sr := range basicResponse.TBSResponseData.Responses {
 		if sr.Good == true && sr.Unknown == true {
 			// Copy good but unknown
-			var s singleResponse
+			var s SingleResponse
 			s.CertID = sr.CertID
 			s.ThisUpdate = sr.ThisUpdate
 			s.NextUpdate = sr.NextUpdate
@@ -164,7 +488,7 @@ func MarshalBasicResponse(basicResponse *BasicResponse) ([]byte, error) {
 			basicResponse.TBSResponseData.Responses[i] = s
 		} else if !sr.Revoked.IsEmpty() && sr.Unknown == true {
 			// Copy revoked but unknown
-			var s singleResponse
+			var s SingleResponse
 			s.CertID = sr.CertID
 			s.ThisUpdate = sr.ThisUpdate
 			s.NextUpdate = sr.NextUpdate
@@ -173,7 +497,7 @@ func MarshalBasicResponse(basicResponse *BasicResponse) ([]byte, error) {
 			basicResponse.TBSResponseData.Responses[i] = s
 		} else if sr.Good == false && sr.Revoked.IsEmpty() {
 			// Set unknown if there was no status.
-			var s singleResponse
+			var s SingleResponse
 			s.CertID = sr.CertID
 			s.ThisUpdate = sr.ThisUpdate
 			s.NextUpdate = sr.NextUpdate
@@ -183,8 +507,96 @@ func MarshalBasicResponse(basicResponse *BasicResponse) ([]byte, error) {
 		}
 
 	}
-	b, err := asn1.Marshal(*basicResponse)
-	return b, err
+}
+
+// AddSingleResponse appends a single response for id and returns its index.
+//
+// The certID is copied exactly as given, including the hash algorithm and its parameters: a response must echo
+// the certID of the request, so pass the one taken from the request rather than one computed by the responder.
+// No status is set, which is marshaled as unknown; set Good or Revoked on the returned index.
+// nextUpdate may be the zero time to leave it out.
+func (basicResponse *BasicResponse) AddSingleResponse(id CertID, thisUpdate, nextUpdate time.Time) int {
+	basicResponse.TBSResponseData.Responses = append(basicResponse.TBSResponseData.Responses, SingleResponse{
+		CertID:     id.clone(),
+		ThisUpdate: thisUpdate,
+		NextUpdate: nextUpdate,
+	})
+	basicResponse.TBSResponseData.Raw = nil
+	return len(basicResponse.TBSResponseData.Responses) - 1
+}
+
+// OrderByRequest reorders the single responses to follow the order of the certIDs in request,
+// for clients matching responses by position.
+//
+// A requested certID without a single response gets an unknown one in its slot, with ProducedAt as its ThisUpdate.
+// Single responses for certIDs that were not requested are kept after the requested ones.
+func (basicResponse *BasicResponse) OrderByRequest(request *OcspRequest) {
+	responses := basicResponse.TBSResponseData.Responses
+	used := make([]bool, len(responses))
+	ordered := make([]SingleResponse, 0, len(request.TBSRequest.RequestList))
+	for _, singleRequest := range request.TBSRequest.RequestList {
+		found := false
+		for i := range responses {
+			if !used[i] && responses[i].CertID.Equal(&singleRequest.ReqCert) {
+				ordered = append(ordered, responses[i])
+				used[i] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			ordered = append(ordered, SingleResponse{
+				CertID:     singleRequest.ReqCert.clone(),
+				Unknown:    true,
+				ThisUpdate: basicResponse.TBSResponseData.ProducedAt,
+			})
+		}
+	}
+	for i := range responses {
+		if !used[i] {
+			ordered = append(ordered, responses[i])
+		}
+	}
+	basicResponse.TBSResponseData.Responses = ordered
+	basicResponse.TBSResponseData.Raw = nil
+}
+
+// EchoExtensions copies the extensions of request whose OID is one of oids into the basic response,
+// replacing extensions with the same OID. With no oids, only the nonce is echoed.
+//
+// Request extensions go to the response extensions, so an echoed nonce is read back with Nonce, not GetNonce.
+// Extensions of a single request go to the single response with an equal certID; single requests without
+// a matching single response are skipped, so EchoExtensions should be called once the single responses are added.
+func (basicResponse *BasicResponse) EchoExtensions(request *OcspRequest, oids ...asn1.ObjectIdentifier) {
+	if len(oids) == 0 {
+		oids = []asn1.ObjectIdentifier{OidOcspNonce}
+	}
+	echo := func(dst, src []pkix.Extension) []pkix.Extension {
+		for _, extension := range src {
+			for _, oid := range oids {
+				if extension.Id.Equal(oid) {
+					dst = setExtension(dst, pkix.Extension{
+						Id:       append(asn1.ObjectIdentifier(nil), extension.Id...),
+						Critical: extension.Critical,
+						Value:    append([]byte(nil), extension.Value...),
+					})
+					break
+				}
+			}
+		}
+		return dst
+	}
+	responseData := &basicResponse.TBSResponseData
+	responseData.ResponseExtensions = echo(responseData.ResponseExtensions, request.TBSRequest.ExtensionList)
+	for _, singleRequest := range request.TBSRequest.RequestList {
+		for i := range responseData.Responses {
+			if responseData.Responses[i].CertID.Equal(&singleRequest.ReqCert) {
+				responseData.Responses[i].SingleExtensions = echo(responseData.Responses[i].SingleExtensions,
+					singleRequest.SingleRequestExtensions)
+			}
+		}
+	}
+	responseData.Raw = nil
 }
 
 func (basicResponse *BasicResponse) SetNonce(index int, nonce []byte) {
@@ -217,8 +629,23 @@ func (basicResponse *BasicResponse) SetNonce(index int, nonce []byte) {
 		}
 	}
 	basicResponse.TBSResponseData.Responses[index].SingleExtensions = extList
+	basicResponse.TBSResponseData.Raw = nil
+}
+
+// Nonce returns the nonce of the response extensions, where RFC 6960 places it and EchoExtensions copies it,
+// or nil for no nonce. A nonce wrapped in an OCTET STRING is returned unwrapped, the same as a raw nonce.
+func (basicResponse *BasicResponse) Nonce() []byte {
+	for _, extension := range basicResponse.TBSResponseData.ResponseExtensions {
+		if extension.Id.Equal(OidOcspNonce) {
+			return nonceValue(extension.Value)
+		}
+	}
+	return nil
 }
 
+// GetNonce returns the nonce of the single response at index, or nil for no nonce.
+// A nonce wrapped in an OCTET STRING is returned unwrapped, the same as a raw nonce.
+// Most responders put the nonce in the response extensions instead, which Nonce reads.
 func (basicResponse *BasicResponse) GetNonce(index int) []byte {
 	extList := basicResponse.TBSResponseData.Responses[index].SingleExtensions
 	if len(extList) == 0 {
@@ -227,7 +654,7 @@ func (basicResponse *BasicResponse) GetNonce(index int) []byte {
 	} else {
 		for _, extension := range extList {
 			if extension.Id.Equal(OidOcspNonce) {
-				return extension.Value
+				return nonceValue(extension.Value)
 			}
 		}
 	}
@@ -235,8 +662,237 @@ func (basicResponse *BasicResponse) GetNonce(index int) []byte {
 	return nil
 }
 
+// SetArchiveCutoff sets the archive cutoff extension of the single response at index, replacing an existing one.
+//
+// The archive cutoff is the earliest date for which the responder still keeps the status of revoked
+// certificates, see RFC 6960 section 4.4.4.
+func (basicResponse *BasicResponse) SetArchiveCutoff(index int, cutoff time.Time) error {
+	value, err := asn1.MarshalWithParams(cutoff.UTC(), "generalized")
+	if err != nil {
+		return err
+	}
+	sr := &basicResponse.TBSResponseData.Responses[index]
+	sr.SingleExtensions = setExtension(sr.SingleExtensions, pkix.Extension{
+		Id:    OidOcspArchiveCutoff,
+		Value: value,
+	})
+	basicResponse.TBSResponseData.Raw = nil
+	return nil
+}
+
+// ApplyRetentionWindow sets the archive cutoff of every single response to ProducedAt minus window,
+// for responders that keep revocation information for a fixed retention period.
+//
+// ProducedAt must be set before calling it.
+func (basicResponse *BasicResponse) ApplyRetentionWindow(window time.Duration) error {
+	cutoff := basicResponse.TBSResponseData.ProducedAt.Add(-window)
+	for i := range basicResponse.TBSResponseData.Responses {
+		if err := basicResponse.SetArchiveCutoff(i, cutoff); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Status returns the certificate status of the single response at index, as it is encoded.
+func (basicResponse *BasicResponse) Status(index int) CertStatus {
+	return basicResponse.TBSResponseData.Responses[index].status()
+}
+
+// RevokedSerials returns the serial numbers of all single responses with a revoked status.
+func (basicResponse *BasicResponse) RevokedSerials() []*big.Int {
+	return basicResponse.serialsWithStatus(StatusRevoked)
+}
+
+// GoodSerials returns the serial numbers of all single responses with a good status.
+func (basicResponse *BasicResponse) GoodSerials() []*big.Int {
+	return basicResponse.serialsWithStatus(StatusGood)
+}
+
+// UnknownSerials returns the serial numbers of all single responses with an unknown status.
+func (basicResponse *BasicResponse) UnknownSerials() []*big.Int {
+	return basicResponse.serialsWithStatus(StatusUnknown)
+}
+
+func (basicResponse *BasicResponse) serialsWithStatus(status CertStatus) []*big.Int {
+	var serials []*big.Int
+	for i := range basicResponse.TBSResponseData.Responses {
+		sr := &basicResponse.TBSResponseData.Responses[i]
+		if sr.status() == status {
+			serials = append(serials, sr.CertID.SerialNumber)
+		}
+	}
+	return serials
+}
+
+// IsExtendedRevokeEnabled reports whether the responder declared the extended revoke extension in the
+// response extensions, see RFC 6960 section 4.4.8.
+func (basicResponse *BasicResponse) IsExtendedRevokeEnabled() bool {
+	for _, extension := range basicResponse.TBSResponseData.ResponseExtensions {
+		if extension.Id.Equal(OidOcspExtendedRevoke) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRevoked reports whether the certificate of the single response at index must be considered revoked.
+//
+// A revoked status is always revoked and a good status is never revoked. An unknown status is revoked only when
+// extended revoke is enabled: such a responder answers revoked for serials it never issued, so an unknown status
+// no longer stands for a possibly non-issued certificate and is rejected. Without extended revoke, an unknown
+// status is not revoked, and the caller decides how to treat it.
+func (basicResponse *BasicResponse) IsRevoked(index int) bool {
+	switch basicResponse.Status(index) {
+	case StatusGood:
+		return false
+	case StatusRevoked:
+		return true
+	}
+	return basicResponse.IsExtendedRevokeEnabled()
+}
+
+// ErrStatusUnknown is returned by StatusWithPolicy for an unknown status under the UnknownAsError policy.
+var ErrStatusUnknown = errors.New("OCSP responder does not know the certificate status")
+
+// UnknownPolicy decides how StatusWithPolicy treats a single response with an unknown status.
+type UnknownPolicy int
+
+const (
+	// UnknownAsError fails with ErrStatusUnknown, leaving the decision to the caller. This is the zero value.
+	UnknownAsError UnknownPolicy = iota
+	// UnknownAsRevoked hard-fails: the certificate is rejected. This is the safe choice against responders that do
+	// not know a certificate because it was not issued by the CA, but rejects valid certificates of a lagging responder.
+	UnknownAsRevoked
+	// UnknownAsGood soft-fails: the certificate is accepted. An attacker holding a forged or mis-issued certificate
+	// the responder does not know about is then accepted as well.
+	UnknownAsGood
+)
+
+// StatusWithPolicy returns the status of the single response at index, mapping an unknown status with policy.
+//
+// A good or revoked status is returned as is. When extended revoke is enabled, an unknown status is reported as
+// revoked whatever the policy, as for IsRevoked: the responder would have answered revoked for a non-issued
+// certificate, so UnknownAsGood cannot be used to accept it.
+func (basicResponse *BasicResponse) StatusWithPolicy(index int, policy UnknownPolicy) (CertStatus, error) {
+	status := basicResponse.Status(index)
+	if status != StatusUnknown {
+		return status, nil
+	}
+	if basicResponse.IsExtendedRevokeEnabled() {
+		return StatusRevoked, nil
+	}
+	switch policy {
+	case UnknownAsRevoked:
+		return StatusRevoked, nil
+	case UnknownAsGood:
+		return StatusGood, nil
+	}
+	return StatusUnknown, ErrStatusUnknown
+}
+
 func (basicResponse *BasicResponse) ClearStatus(index int) {
 	basicResponse.TBSResponseData.Responses[index].Good = false
 	basicResponse.TBSResponseData.Responses[index].Unknown = false
 	basicResponse.TBSResponseData.Responses[index].Revoked = RevokedInfo{}
+	basicResponse.TBSResponseData.Raw = nil
+}
+
+// TimeToExpiry returns how long the response stays valid after now, which is the minimum of
+// NextUpdate - now over all single responses.
+//
+// The duration is negative if a single response has already expired.
+// It returns NoExpiry if any single response lacks a NextUpdate, or if there is no single response.
+func (basicResponse *BasicResponse) TimeToExpiry(now time.Time) time.Duration {
+	responses := basicResponse.TBSResponseData.Responses
+	if len(responses) == 0 {
+		return NoExpiry
+	}
+	ttl := NoExpiry
+	for _, sr := range responses {
+		if sr.NextUpdate.IsZero() {
+			return NoExpiry
+		}
+		if d := sr.NextUpdate.Sub(now); d < ttl {
+			ttl = d
+		}
+	}
+	return ttl
+}
+
+// ResponderCertificates parses all certificates embedded in the basic response.
+//
+// Besides the responder certificate, responders may embed other certificates such as cross-signed CAs,
+// so the result is neither guaranteed to form a chain nor to start with the signer.
+func (basicResponse *BasicResponse) ResponderCertificates() ([]*x509.Certificate, error) {
+	certs := make([]*x509.Certificate, 0, len(basicResponse.Certs))
+	for _, raw := range basicResponse.Certs {
+		cert, err := x509.ParseCertificate(raw.FullBytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
+// responderCertificate returns the embedded certificate identified by the ResponderID,
+// wherever it is in the list, or nil if none of the embedded certificates matches.
+func (basicResponse *BasicResponse) responderCertificate() (*x509.Certificate, error) {
+	certs, err := basicResponse.ResponderCertificates()
+	if err != nil {
+		return nil, err
+	}
+	for _, cert := range certs {
+		if basicResponse.isResponder(cert) {
+			return cert, nil
+		}
+	}
+	return nil, nil
+}
+
+// isResponder reports whether cert is identified by the ResponderID, either by name or by the
+// SHA-1 hash of its public key.
+func (basicResponse *BasicResponse) isResponder(cert *x509.Certificate) bool {
+	responderID := basicResponse.TBSResponseData.ResponderID
+	if responderID.Class != asn1.ClassContextSpecific {
+		return false
+	}
+	switch responderID.Tag {
+	case 1:
+		return bytes.Equal(responderID.Bytes, cert.RawSubject)
+	case 2:
+		var keyHash []byte
+		if _, err := asn1.Unmarshal(responderID.Bytes, &keyHash); err != nil {
+			return false
+		}
+		h, err := issuerKeyHash(crypto.SHA1, cert)
+		if err != nil {
+			return false
+		}
+		return bytes.Equal(keyHash, h)
+	}
+	return false
+}
+
+// SignedData returns what is needed to verify the basic response again later, for example when
+// archiving it as long-term validation evidence: the exact signed responseData bytes, the signature value
+// and the signature algorithm.
+//
+// For a parsed response, tbs is the responseData exactly as received.
+func (basicResponse *BasicResponse) SignedData() (tbs []byte, sig []byte, alg pkix.AlgorithmIdentifier, err error) {
+	tbs, err = basicResponse.tbsResponseDataBytes()
+	if err != nil {
+		return nil, nil, pkix.AlgorithmIdentifier{}, err
+	}
+	return tbs, basicResponse.Signature.RightAlign(), basicResponse.SignatureAlgorithm, nil
+}
+
+// tbsResponseDataBytes returns the DER encoding of the signed responseData.
+// The bytes captured on unmarshaling are preferred, as re-encoding may not reproduce them exactly.
+func (basicResponse *BasicResponse) tbsResponseDataBytes() ([]byte, error) {
+	if len(basicResponse.TBSResponseData.Raw) > 0 {
+		return basicResponse.TBSResponseData.Raw, nil
+	}
+	return asn1.Marshal(basicResponse.TBSResponseData)
 }
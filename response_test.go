@@ -0,0 +1,294 @@
+package gocsp
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/asn1"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMarshalBasicResponseEncodesChangedFields(t *testing.T) {
+	br, err := UnmarshalResponseToBasic(readTestFile(t, "resp-good.der"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	revokedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	producedAt := br.TBSResponseData.ProducedAt.Add(time.Hour)
+	br.TBSResponseData.Responses[0].Good = false
+	br.TBSResponseData.Responses[0].Revoked.RevocationTime = revokedAt
+	br.TBSResponseData.ProducedAt = producedAt
+
+	der, err := MarshalBasicResponse(br)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := UnmarshalBasicResponse(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status := got.Status(0); status != StatusRevoked {
+		t.Errorf("status = %v, want revoked", status)
+	}
+	if !got.TBSResponseData.Responses[0].Revoked.RevocationTime.Equal(revokedAt) {
+		t.Errorf("revocation time = %v, want %v", got.TBSResponseData.Responses[0].Revoked.RevocationTime, revokedAt)
+	}
+	if !got.TBSResponseData.ProducedAt.Equal(producedAt) {
+		t.Errorf("ProducedAt = %v, want %v", got.TBSResponseData.ProducedAt, producedAt)
+	}
+}
+
+func TestVerifyWithKeyUsesReceivedBytes(t *testing.T) {
+	br, err := UnmarshalResponseToBasic(readTestFile(t, "resp-good.der"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyWithKey(br, testIssuer(t).PublicKey); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUnmarshalGoodStatusEncodings(t *testing.T) {
+	// resp-explicit-null-good.der is resp-good.der with its good status rewritten as [0] EXPLICIT NULL
+	// (A0 02 05 00) and signed again with the CA key.
+	for _, name := range []string{"resp-good.der", "resp-explicit-null-good.der"} {
+		t.Run(name, func(t *testing.T) {
+			br, err := UnmarshalResponseToBasic(readTestFile(t, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if br.TBSResponseData.Responses[0].Good != true {
+				t.Error("Good is not set")
+			}
+			if status := br.Status(0); status != StatusGood {
+				t.Errorf("status = %v, want good", status)
+			}
+			if err := VerifyWithKey(br, testIssuer(t).PublicKey); err != nil {
+				t.Errorf("VerifyWithKey: %v", err)
+			}
+		})
+	}
+}
+
+func TestMarshalExplicitNullGoodStatusAsImplicit(t *testing.T) {
+	br, err := UnmarshalResponseToBasic(readTestFile(t, "resp-explicit-null-good.der"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(br.TBSResponseData.Raw, []byte{0xa0, 0x02, 0x05, 0x00}) {
+		t.Error("the received responseData bytes do not keep the explicit NULL")
+	}
+	der, err := MarshalBasicResponse(br)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(der, []byte{0xa0, 0x02, 0x05, 0x00}) {
+		t.Error("the explicit NULL is marshaled again")
+	}
+	got, err := UnmarshalBasicResponse(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The signature covers the explicit NULL, so it does not verify over the re-marshaled responseData.
+	if err := VerifyWithKey(got, testIssuer(t).PublicKey); err == nil {
+		t.Error("VerifyWithKey succeeds on the re-marshaled response")
+	}
+	if status := got.Status(0); status != StatusGood {
+		t.Errorf("status = %v, want good", status)
+	}
+}
+
+func TestOrderByRequest(t *testing.T) {
+	issuer := testIssuer(t)
+	ids := make([]CertID, 4)
+	for i := range ids {
+		id, err := NewCertID(crypto.SHA1, issuer, big.NewInt(int64(i+1)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[i] = id
+	}
+	producedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	br := &BasicResponse{}
+	br.TBSResponseData.ProducedAt = producedAt
+	// Answer serials 3, 1 and the unrequested 4, but not 2.
+	for _, i := range []int{2, 0, 3} {
+		j := br.AddSingleResponse(ids[i], producedAt, time.Time{})
+		br.TBSResponseData.Responses[j].Good = true
+	}
+	r := &OcspRequest{}
+	for _, i := range []int{0, 1, 2} {
+		r.TBSRequest.RequestList = append(r.TBSRequest.RequestList, request{ReqCert: ids[i]})
+	}
+
+	br.OrderByRequest(r)
+	want := []struct {
+		serial int64
+		status CertStatus
+	}{
+		{1, StatusGood},
+		{2, StatusUnknown},
+		{3, StatusGood},
+		{4, StatusGood},
+	}
+	responses := br.TBSResponseData.Responses
+	if len(responses) != len(want) {
+		t.Fatalf("got %d single responses, want %d", len(responses), len(want))
+	}
+	for i, w := range want {
+		if serial := responses[i].CertID.SerialNumber.Int64(); serial != w.serial {
+			t.Errorf("single response %d has serial %d, want %d", i, serial, w.serial)
+		}
+		if status := br.Status(i); status != w.status {
+			t.Errorf("single response %d has status %v, want %v", i, status, w.status)
+		}
+	}
+	if !responses[1].ThisUpdate.Equal(producedAt) {
+		t.Errorf("unknown slot ThisUpdate = %v, want ProducedAt", responses[1].ThisUpdate)
+	}
+}
+
+func TestUnmarshalResponseVersion(t *testing.T) {
+	// resp-good.der omits the version, as OpenSSL does. resp-explicit-version.der is the same response
+	// with the version explicitly encoded as [0] INTEGER 0, signed again with the CA key.
+	tests := []struct {
+		name     string
+		explicit bool
+	}{
+		{"resp-good.der", false},
+		{"resp-explicit-version.der", true},
+	}
+	explicitVersion := []byte{0xa0, 0x03, 0x02, 0x01, 0x00}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			br, err := UnmarshalResponseToBasic(readTestFile(t, tt.name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if br.TBSResponseData.Version != Version1 {
+				t.Errorf("Version = %d, want %d", br.TBSResponseData.Version, Version1)
+			}
+			// The version is the first field of responseData.
+			var responseData asn1.RawValue
+			if _, err := asn1.Unmarshal(br.TBSResponseData.Raw, &responseData); err != nil {
+				t.Fatal(err)
+			}
+			if got := bytes.HasPrefix(responseData.Bytes, explicitVersion); got != tt.explicit {
+				t.Errorf("explicit version in the received bytes = %v, want %v", got, tt.explicit)
+			}
+			if err := VerifyWithKey(br, testIssuer(t).PublicKey); err != nil {
+				t.Errorf("VerifyWithKey: %v", err)
+			}
+		})
+	}
+}
+
+func BenchmarkUnmarshalResponse(b *testing.B) {
+	der := readTestFile(b, "resp-good.der")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := UnmarshalResponse(der); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalResponseInto(b *testing.B) {
+	der := readTestFile(b, "resp-good.der")
+	var dst OcspResponse
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := UnmarshalResponseInto(der, &dst); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestUnmarshalResponseIntoReusesDst(t *testing.T) {
+	otherType, err := MarshalResponse(&OcspResponse{ResponseBytes: responseBytes{
+		ResponseType: asn1.ObjectIdentifier{1, 2, 3, 4},
+		Response:     []byte{0x01, 0x02},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tryLater, err := MarshalResponse(&OcspResponse{ResponseStatus: asn1.Enumerated(TryLater)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Decode every response into the same dst, after a basic response, so that stale data would show.
+	inputs := [][]byte{
+		readTestFile(t, "resp-good.der"),
+		otherType,
+		readTestFile(t, "resp-good.der"),
+		tryLater,
+		readTestFile(t, "resp-explicit-version.der"),
+	}
+	var dst OcspResponse
+	for i, der := range inputs {
+		want, err := UnmarshalResponse(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := UnmarshalResponseInto(der, &dst); err != nil {
+			t.Fatalf("input %d: %v", i, err)
+		}
+		if dst.ResponseStatus != want.ResponseStatus ||
+			!dst.ResponseBytes.ResponseType.Equal(want.ResponseBytes.ResponseType) ||
+			!bytes.Equal(dst.ResponseBytes.Response, want.ResponseBytes.Response) {
+			t.Errorf("input %d: UnmarshalResponseInto = %+v, want %+v", i, dst, *want)
+		}
+		if want.ResponseBytes.ResponseType == nil && !reflect.DeepEqual(dst, *want) {
+			t.Errorf("input %d: response bytes of the previous response are kept: %+v", i, dst)
+		}
+	}
+}
+
+func TestUnmarshalResponseIntoRejectsMalformed(t *testing.T) {
+	der := readTestFile(t, "resp-good.der")
+	// A constructed OCTET STRING is not DER. The response OCTET STRING follows the response type.
+	constructed := append([]byte(nil), der...)
+	constructed[bytes.Index(der, oidOcspBasicResponseDER)+len(oidOcspBasicResponseDER)] |= 0x20
+	tests := []struct {
+		name string
+		der  []byte
+	}{
+		{"trailing data", append(append([]byte(nil), der...), 0x00)},
+		{"truncated", der[:len(der)-1]},
+		{"constructed response", constructed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dst OcspResponse
+			if err := UnmarshalResponseInto(tt.der, &dst); err == nil {
+				t.Error("UnmarshalResponseInto accepts a malformed response")
+			}
+			if _, err := UnmarshalResponse(tt.der); err == nil {
+				t.Error("UnmarshalResponse accepts a malformed response")
+			}
+		})
+	}
+}
+
+func TestEchoExtensionsNonce(t *testing.T) {
+	id, err := NewCertID(crypto.SHA1, testIssuer(t), big.NewInt(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := []byte("0123456789abcdef")
+	r := &OcspRequest{}
+	r.TBSRequest.RequestList = []request{{ReqCert: id}}
+	r.SetNonce(nonce)
+	br := &BasicResponse{}
+	br.AddSingleResponse(id, time.Now(), time.Time{})
+
+	if br.Nonce() != nil {
+		t.Fatal("Nonce is set before echoing")
+	}
+	br.EchoExtensions(r)
+	if !bytes.Equal(br.Nonce(), nonce) {
+		t.Errorf("Nonce = %x, want %x", br.Nonce(), nonce)
+	}
+}
@@ -0,0 +1,169 @@
+package gocsp
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"io"
+)
+
+var (
+	oidSignatureSHA1WithRSA     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 5}
+	oidSignatureSHA256WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	oidSignatureSHA384WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 12}
+	oidSignatureSHA512WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 13}
+	oidSignatureECDSAWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 1}
+	oidSignatureECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	oidSignatureECDSAWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 3}
+	oidSignatureECDSAWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 4}
+	oidSignatureEd25519         = asn1.ObjectIdentifier{1, 3, 101, 112}
+)
+
+// signatureAlgorithms maps the signature algorithm OIDs accepted in OCSP messages to their x509 equivalent.
+var signatureAlgorithms = []struct {
+	oid       asn1.ObjectIdentifier
+	algorithm x509.SignatureAlgorithm
+}{
+	{oidSignatureSHA1WithRSA, x509.SHA1WithRSA},
+	{oidSignatureSHA256WithRSA, x509.SHA256WithRSA},
+	{oidSignatureSHA384WithRSA, x509.SHA384WithRSA},
+	{oidSignatureSHA512WithRSA, x509.SHA512WithRSA},
+	{oidSignatureECDSAWithSHA1, x509.ECDSAWithSHA1},
+	{oidSignatureECDSAWithSHA256, x509.ECDSAWithSHA256},
+	{oidSignatureECDSAWithSHA384, x509.ECDSAWithSHA384},
+	{oidSignatureECDSAWithSHA512, x509.ECDSAWithSHA512},
+	{oidSignatureEd25519, x509.PureEd25519},
+}
+
+// signatureAlgorithmFromOID returns the x509 signature algorithm for oid, or x509.UnknownSignatureAlgorithm.
+func signatureAlgorithmFromOID(oid asn1.ObjectIdentifier) x509.SignatureAlgorithm {
+	for _, details := range signatureAlgorithms {
+		if oid.Equal(details.oid) {
+			return details.algorithm
+		}
+	}
+	return x509.UnknownSignatureAlgorithm
+}
+
+// checkSignature verifies signature over signed with publicKey, using the signature algorithm identified by algorithm.
+func checkSignature(algorithm pkix.AlgorithmIdentifier, signed []byte, signature asn1.BitString, publicKey crypto.PublicKey) error {
+	signatureAlgorithm := signatureAlgorithmFromOID(algorithm.Algorithm)
+	if signatureAlgorithm == x509.UnknownSignatureAlgorithm {
+		return x509.ErrUnsupportedAlgorithm
+	}
+	// CheckSignature only uses the public key of the certificate.
+	key := &x509.Certificate{PublicKey: publicKey}
+	return key.CheckSignature(signatureAlgorithm, signed, signature.RightAlign())
+}
+
+// ContextSigner is implemented by signers whose signing operation can be cancelled,
+// such as keys kept in a remote HSM or KMS.
+//
+// SignBasicResponseContext uses SignContext instead of Sign when the signer implements it.
+type ContextSigner interface {
+	SignContext(ctx context.Context, rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error)
+}
+
+// SignBasicResponse signs the basic response with signer, setting its SignatureAlgorithm and Signature.
+//
+// The signature algorithm is chosen from the type of the public key: SHA-256 with RSA, ECDSA with the hash
+// matching the curve size, or Ed25519. The signed response can then be marshaled with MarshalResponseFromBasic.
+func SignBasicResponse(basicResponse *BasicResponse, signer crypto.Signer) error {
+	return SignBasicResponseContext(context.Background(), basicResponse, signer)
+}
+
+// SignBasicResponseContext is like SignBasicResponse, but passes ctx to signers implementing ContextSigner.
+// Other signers are only called if ctx is not done yet.
+func SignBasicResponseContext(ctx context.Context, basicResponse *BasicResponse, signer crypto.Signer) error {
+	return SignBasicResponseWithOptions(ctx, basicResponse, signer, SignOptions{})
+}
+
+// SignOptions configures the profile checks done by SignBasicResponseWithOptions before signing.
+type SignOptions struct {
+	// RequireNextUpdate rejects single responses without a NextUpdate, as required by profiles such as RFC 5019.
+	RequireNextUpdate bool
+	// DeterministicECDSA signs ECDSA responses with the deterministic nonce of RFC 6979 instead of a random one,
+	// so that the same response always gets the same signature. The signer must then be an *ecdsa.PrivateKey.
+	// It is meant for golden-byte tests and reproducible output, not for production responders.
+	DeterministicECDSA bool
+}
+
+// SignBasicResponseWithOptions is like SignBasicResponseContext, but first checks the basic response against opts.
+func SignBasicResponseWithOptions(ctx context.Context, basicResponse *BasicResponse, signer crypto.Signer, opts SignOptions) error {
+	if len(basicResponse.TBSResponseData.Responses) == 0 {
+		return ErrNoSingleResponses
+	}
+	if opts.RequireNextUpdate {
+		if err := basicResponse.checkNextUpdate(); err != nil {
+			return err
+		}
+	}
+	if opts.DeterministicECDSA {
+		var err error
+		if signer, err = deterministicSigner(signer); err != nil {
+			return err
+		}
+	}
+	signatureAlgorithm, hash, err := signingAlgorithm(signer.Public())
+	if err != nil {
+		return err
+	}
+	basicResponse.normalizeStatuses()
+	// Sign the current content, not the bytes it was parsed from.
+	basicResponse.TBSResponseData.Raw = nil
+	tbs, err := asn1.Marshal(basicResponse.TBSResponseData)
+	if err != nil {
+		return err
+	}
+	digest := tbs
+	if hash != 0 {
+		h := hash.New()
+		h.Write(tbs)
+		digest = h.Sum(nil)
+	}
+	var sig []byte
+	if contextSigner, ok := signer.(ContextSigner); ok {
+		sig, err = contextSigner.SignContext(ctx, rand.Reader, digest, hash)
+	} else if err = ctx.Err(); err == nil {
+		sig, err = signer.Sign(rand.Reader, digest, hash)
+	}
+	if err != nil {
+		return err
+	}
+	basicResponse.TBSResponseData.Raw = tbs
+	basicResponse.SignatureAlgorithm = signatureAlgorithm
+	basicResponse.Signature = asn1.BitString{Bytes: sig, BitLength: 8 * len(sig)}
+	return nil
+}
+
+// signingAlgorithm returns the signature algorithm identifier and hash used to sign with publicKey.
+func signingAlgorithm(publicKey crypto.PublicKey) (pkix.AlgorithmIdentifier, crypto.Hash, error) {
+	switch pub := publicKey.(type) {
+	case *rsa.PublicKey:
+		return pkix.AlgorithmIdentifier{
+			Algorithm:  oidSignatureSHA256WithRSA,
+			Parameters: asn1.NullRawValue,
+		}, crypto.SHA256, nil
+	case *ecdsa.PublicKey:
+		switch pub.Curve {
+		case elliptic.P256():
+			return pkix.AlgorithmIdentifier{Algorithm: oidSignatureECDSAWithSHA256}, crypto.SHA256, nil
+		case elliptic.P384():
+			return pkix.AlgorithmIdentifier{Algorithm: oidSignatureECDSAWithSHA384}, crypto.SHA384, nil
+		case elliptic.P521():
+			return pkix.AlgorithmIdentifier{Algorithm: oidSignatureECDSAWithSHA512}, crypto.SHA512, nil
+		}
+		return pkix.AlgorithmIdentifier{}, 0, errors.New("unsupported elliptic curve for OCSP signing")
+	case ed25519.PublicKey:
+		return pkix.AlgorithmIdentifier{Algorithm: oidSignatureEd25519}, 0, nil
+	}
+	return pkix.AlgorithmIdentifier{}, 0, errors.New("unsupported public key type for OCSP signing")
+}
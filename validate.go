@@ -0,0 +1,209 @@
+package gocsp
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrResponseNotYetValid       = errors.New("OCSP single response is not valid yet")
+	ErrResponseExpired           = errors.New("OCSP single response has expired")
+	ErrThisUpdateAfterProducedAt = errors.New("OCSP single response ThisUpdate is later than the response ProducedAt")
+	ErrMissingNextUpdate         = errors.New("OCSP single response has no NextUpdate")
+	ErrNextUpdateTooFar          = errors.New("OCSP single response NextUpdate is too far in the future")
+)
+
+// handledExtensions lists the extensions this package understands, so that Validate accepts them when critical.
+var handledExtensions = []asn1.ObjectIdentifier{
+	OidOcspNonce,
+	OidOcspArchiveCutoff,
+	OidOcspExtendedRevoke,
+}
+
+// UnhandledExtension is a critical extension of a basic response that this package does not understand.
+type UnhandledExtension struct {
+	OID   asn1.ObjectIdentifier
+	Value []byte
+	// Location is "responseExtensions" or "singleExtensions[i]" for the single response at index i.
+	Location string
+}
+
+// UnhandledExtensionError is returned by Validate for a basic response with an unhandled critical extension.
+type UnhandledExtensionError struct {
+	Extension UnhandledExtension
+}
+
+func (e *UnhandledExtensionError) Error() string {
+	return "unhandled critical extension " + e.Extension.OID.String() + " in " + e.Extension.Location
+}
+
+// UnhandledCriticalExtensions returns the critical extensions of the basic response that this package
+// does not understand, with their raw value and where they were found.
+func (basicResponse *BasicResponse) UnhandledCriticalExtensions() []UnhandledExtension {
+	var unhandled []UnhandledExtension
+	collect := func(extensions []pkix.Extension, location string) {
+		for _, extension := range extensions {
+			if extension.Critical && !isHandledExtension(extension.Id) {
+				unhandled = append(unhandled, UnhandledExtension{
+					OID:      extension.Id,
+					Value:    extension.Value,
+					Location: location,
+				})
+			}
+		}
+	}
+	collect(basicResponse.TBSResponseData.ResponseExtensions, "responseExtensions")
+	for i, sr := range basicResponse.TBSResponseData.Responses {
+		collect(sr.SingleExtensions, "singleExtensions["+strconv.Itoa(i)+"]")
+	}
+	return unhandled
+}
+
+func isHandledExtension(oid asn1.ObjectIdentifier) bool {
+	for _, handled := range handledExtensions {
+		if oid.Equal(handled) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateOptions configures the checks done by BasicResponse.Validate.
+type ValidateOptions struct {
+	// Now is the time to validate at. The zero value means time.Now.
+	Now time.Time
+	// Skew is the clock difference tolerated in every time comparison.
+	Skew time.Duration
+	// CheckProducedAt rejects single responses whose ThisUpdate is later than the ProducedAt of the response.
+	// A responder cannot sign status information it did not have yet, so this indicates a responder bug or tampering.
+	CheckProducedAt bool
+	// RequireNextUpdate rejects single responses without a NextUpdate, for profiles such as RFC 5019
+	// where responses must say until when they can be cached.
+	RequireNextUpdate bool
+	// MaxNextUpdateHorizon, if not zero, rejects single responses whose NextUpdate is more than this duration
+	// after Now. This bounds how long a response, possibly obtained from a compromised responder, can be relied on.
+	MaxNextUpdateHorizon time.Duration
+}
+
+// Validate checks the validity interval of every single response of the basic response: ThisUpdate must not be
+// in the future and NextUpdate, when present, must not be in the past. It also runs the optional checks of opts.
+// A critical extension this package does not understand fails with an *UnhandledExtensionError.
+//
+// The signature is not verified. The error of the first failing single response is returned, naming its index.
+func (basicResponse *BasicResponse) Validate(opts ValidateOptions) error {
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	if unhandled := basicResponse.UnhandledCriticalExtensions(); len(unhandled) > 0 {
+		return &UnhandledExtensionError{Extension: unhandled[0]}
+	}
+	producedAt := basicResponse.TBSResponseData.ProducedAt
+	for i, sr := range basicResponse.TBSResponseData.Responses {
+		if sr.ThisUpdate.After(now.Add(opts.Skew)) {
+			return fmt.Errorf("single response %d: %w", i, ErrResponseNotYetValid)
+		}
+		if !sr.NextUpdate.IsZero() && sr.NextUpdate.Before(now.Add(-opts.Skew)) {
+			return fmt.Errorf("single response %d: %w", i, ErrResponseExpired)
+		}
+		if opts.MaxNextUpdateHorizon != 0 && sr.NextUpdate.After(now.Add(opts.MaxNextUpdateHorizon+opts.Skew)) {
+			return fmt.Errorf("single response %d: %w", i, ErrNextUpdateTooFar)
+		}
+		if opts.CheckProducedAt && sr.ThisUpdate.After(producedAt.Add(opts.Skew)) {
+			return fmt.Errorf("single response %d: %w", i, ErrThisUpdateAfterProducedAt)
+		}
+	}
+	if opts.RequireNextUpdate {
+		return basicResponse.checkNextUpdate()
+	}
+	return nil
+}
+
+// checkNextUpdate returns ErrMissingNextUpdate, naming the index, for the first single response without a NextUpdate.
+func (basicResponse *BasicResponse) checkNextUpdate() error {
+	for i, sr := range basicResponse.TBSResponseData.Responses {
+		if sr.NextUpdate.IsZero() {
+			return fmt.Errorf("single response %d: %w", i, ErrMissingNextUpdate)
+		}
+	}
+	return nil
+}
+
+// Bounds of the nonce length accepted by ValidateRequest, as recommended by RFC 8954 section 2.1.
+const (
+	minNonceLength = 1
+	maxNonceLength = 32
+)
+
+// handledRequestExtensions lists the request extensions this package understands, so that ValidateRequest
+// accepts them when critical.
+var handledRequestExtensions = []asn1.ObjectIdentifier{
+	OidOcspNonce,
+	OidOcspAcceptableResponses,
+}
+
+// RequestError is returned by ValidateRequest for a request a responder should not answer.
+//
+// Status is the response status to answer with, which is always MalformedRequest.
+type RequestError struct {
+	Status ResponseStatus
+	Reason string
+}
+
+func (e *RequestError) Error() string {
+	return "malformed OCSP request: " + e.Reason
+}
+
+// ValidateRequest checks an incoming request before it is answered: the version must be Version1, there must be
+// at least one certID, the hashes of certIDs with a supported hash algorithm must have its digest size, a nonce
+// must be between 1 and 32 bytes, and every critical extension, of the request or of a single request, must be
+// understood by this package.
+//
+// A failing check returns a *RequestError whose Status is MalformedRequest. The signature is not verified.
+func ValidateRequest(r *OcspRequest) error {
+	malformed := func(reason string) error {
+		return &RequestError{Status: MalformedRequest, Reason: reason}
+	}
+	if r.Version() != Version1 {
+		return malformed("unsupported version " + strconv.Itoa(r.Version()))
+	}
+	if len(r.TBSRequest.RequestList) == 0 {
+		return malformed("no certID")
+	}
+	for i, singleRequest := range r.TBSRequest.RequestList {
+		if _, err := singleRequest.ReqCert.Hash(); err != nil && err != errUnsupportedHash {
+			return malformed("single request " + strconv.Itoa(i) + ": " + err.Error())
+		}
+		for _, extension := range singleRequest.SingleRequestExtensions {
+			if extension.Critical {
+				return malformed("unhandled critical extension " + extension.Id.String() +
+					" in singleRequestExtensions[" + strconv.Itoa(i) + "]")
+			}
+		}
+	}
+	for _, extension := range r.TBSRequest.ExtensionList {
+		if extension.Critical && !isHandledRequestExtension(extension.Id) {
+			return malformed("unhandled critical extension " + extension.Id.String() + " in requestExtensions")
+		}
+		if extension.Id.Equal(OidOcspNonce) {
+			nonce := nonceValue(extension.Value)
+			if len(nonce) < minNonceLength || len(nonce) > maxNonceLength {
+				return malformed("nonce length " + strconv.Itoa(len(nonce)) + " is out of bounds")
+			}
+		}
+	}
+	return nil
+}
+
+func isHandledRequestExtension(oid asn1.ObjectIdentifier) bool {
+	for _, handled := range handledRequestExtensions {
+		if oid.Equal(handled) {
+			return true
+		}
+	}
+	return false
+}
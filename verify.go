@@ -0,0 +1,133 @@
+package gocsp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+)
+
+// https://tools.ietf.org/html/rfc6960#section-4.2.2.2
+
+var (
+	ErrResponderNotAuthorized  = errors.New("responder certificate is not authorized for OCSP signing")
+	ErrResponderIssuerMismatch = errors.New("responder certificate is not issued by the CA")
+	ErrResponderKeyIDMismatch  = errors.New("responder certificate authority key identifier does not match the CA")
+	ErrResponderKeyUsage       = errors.New("responder certificate key usage does not allow digital signatures")
+)
+
+// ValidateDelegatedResponder checks that responder is a valid delegated OCSP responder for issuer.
+//
+// The responder certificate must carry the OCSPSigning extended key usage and be issued directly by issuer,
+// which is checked by name and by signature. If the responder certificate has an AuthorityKeyIdentifier
+// and the issuer has a SubjectKeyIdentifier, they must also be equal; this catches issuers whose names
+// collide but whose keys differ. The check is skipped when either extension is absent.
+// If the responder certificate has a KeyUsage extension, it must include digitalSignature (RFC 5280 section 4.2.1.3);
+// without the extension, all key usages are allowed.
+func ValidateDelegatedResponder(responder, issuer *x509.Certificate) error {
+	authorized := false
+	for _, eku := range responder.ExtKeyUsage {
+		if eku == x509.ExtKeyUsageOCSPSigning {
+			authorized = true
+			break
+		}
+	}
+	if !authorized {
+		return ErrResponderNotAuthorized
+	}
+	// x509 leaves KeyUsage at zero when the extension is absent.
+	if responder.KeyUsage != 0 && responder.KeyUsage&x509.KeyUsageDigitalSignature == 0 {
+		return ErrResponderKeyUsage
+	}
+	if !bytes.Equal(responder.RawIssuer, issuer.RawSubject) {
+		return ErrResponderIssuerMismatch
+	}
+	if len(responder.AuthorityKeyId) > 0 && len(issuer.SubjectKeyId) > 0 &&
+		!bytes.Equal(responder.AuthorityKeyId, issuer.SubjectKeyId) {
+		return ErrResponderKeyIDMismatch
+	}
+	return responder.CheckSignatureFrom(issuer)
+}
+
+// VerifyWithKey verifies the signature of a directly signed basic response with the issuer's public key.
+//
+// It is meant for callers that only hold a pinned issuer key and no certificate. Unlike a certificate-based
+// verification, the embedded certificates are ignored and delegated responders are not considered, so a response
+// signed by a delegated responder fails. An error is also returned if the signature algorithm is not supported
+// or does not match the type of issuerKey.
+func VerifyWithKey(br *BasicResponse, issuerKey crypto.PublicKey) error {
+	tbs, err := br.tbsResponseDataBytes()
+	if err != nil {
+		return err
+	}
+	return checkSignature(br.SignatureAlgorithm, tbs, br.Signature, issuerKey)
+}
+
+// VerifyOptions configures Verify.
+type VerifyOptions struct {
+	// Roots are the trust anchors delegated responder certificates must chain to.
+	// If nil, the system certificate pool is used.
+	Roots *x509.CertPool
+	// ExtraResponderCerts are candidate delegated responder certificates known to the caller, for responders
+	// that do not embed their certificate in the response. They are only consulted when none of the embedded
+	// certificates matches the ResponderID.
+	ExtraResponderCerts []*x509.Certificate
+}
+
+// VerifyWithSystemRoots verifies the signature of a basic response for certificates issued by issuer.
+//
+// If the response is signed by issuer itself, its signature is checked with the issuer key. This includes a responder
+// certificate with the key of issuer, such as a cross-signed copy of the issuer embedded in the response.
+// If it is signed by a delegated responder embedded in the response, the responder certificate must satisfy
+// ValidateDelegatedResponder and chain, through issuer, to a root of the system certificate pool at the time
+// the response was produced. An error is returned if the system pool is not available on this platform.
+func VerifyWithSystemRoots(br *BasicResponse, issuer *x509.Certificate) error {
+	return Verify(br, issuer, VerifyOptions{})
+}
+
+// Verify is like VerifyWithSystemRoots, but takes its trust anchors and additional candidate responder
+// certificates from opts. A candidate from ExtraResponderCerts is selected by the ResponderID and must pass
+// the same checks as an embedded responder certificate.
+func Verify(br *BasicResponse, issuer *x509.Certificate, opts VerifyOptions) error {
+	responder, err := br.responderCertificate()
+	if err != nil {
+		return err
+	}
+	if responder == nil {
+		for _, cert := range opts.ExtraResponderCerts {
+			if br.isResponder(cert) {
+				responder = cert
+				break
+			}
+		}
+	}
+	// A certificate with the issuer key, such as a cross-signed copy of the issuer, means the issuer signed directly.
+	if responder == nil || bytes.Equal(responder.RawSubjectPublicKeyInfo, issuer.RawSubjectPublicKeyInfo) {
+		return VerifyWithKey(br, issuer.PublicKey)
+	}
+	if err := ValidateDelegatedResponder(responder, issuer); err != nil {
+		return err
+	}
+	roots := opts.Roots
+	if roots == nil {
+		roots, err = x509.SystemCertPool()
+		if err != nil {
+			return fmt.Errorf("system certificate pool is not available: %w", err)
+		}
+	}
+	intermediates := x509.NewCertPool()
+	intermediates.AddCert(issuer)
+	// ValidateDelegatedResponder has checked the OCSPSigning usage of the responder itself. Requiring it here
+	// would also require it from every CA of the path, which public intermediates do not carry.
+	_, err = responder.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   br.TBSResponseData.ProducedAt,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return err
+	}
+	return VerifyWithKey(br, responder.PublicKey)
+}
@@ -0,0 +1,214 @@
+package gocsp
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+	"time"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// newTestCert issues a certificate from template, signed by parent, or self-signed if parent is nil.
+func newTestCert(t testing.TB, template *x509.Certificate, parent *testCA) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	issuerCert, issuerKey := template, key
+	if parent != nil {
+		issuerCert, issuerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuerCert, key.Public(), issuerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key}
+}
+
+func newTestCACert(t testing.TB, name string, parent *testCA, extKeyUsage ...x509.ExtKeyUsage) *testCA {
+	return newTestCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           extKeyUsage,
+	}, parent)
+}
+
+func newTestResponderCert(t testing.TB, name string, issuer *testCA) *testCA {
+	return newTestCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: name},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+	}, issuer)
+}
+
+// newSignedTestResponse returns a response for serial 5 of issuer, signed by signer and naming it as the responder.
+func newSignedTestResponse(t testing.TB, issuer, signer *testCA, embedded ...*x509.Certificate) *BasicResponse {
+	t.Helper()
+	id, err := NewCertID(crypto.SHA1, issuer.cert, big.NewInt(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	br := &BasicResponse{}
+	br.TBSResponseData.ResponderID = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 1, IsCompound: true, Bytes: signer.cert.RawSubject}
+	br.TBSResponseData.ProducedAt = now
+	i := br.AddSingleResponse(id, now, time.Time{})
+	br.TBSResponseData.Responses[i].Good = true
+	for _, cert := range embedded {
+		br.Certs = append(br.Certs, asn1.RawValue{FullBytes: cert.Raw})
+	}
+	if err := SignBasicResponse(br, signer.key); err != nil {
+		t.Fatal(err)
+	}
+	return br
+}
+
+func TestVerifyDelegateUnderEKURestrictedIntermediate(t *testing.T) {
+	root := newTestCACert(t, "Root", nil)
+	intermediate := newTestCACert(t, "Intermediate", root, x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth)
+	responder := newTestResponderCert(t, "Responder", intermediate)
+	roots := x509.NewCertPool()
+	roots.AddCert(root.cert)
+
+	br := newSignedTestResponse(t, intermediate, responder, responder.cert)
+	if err := Verify(br, intermediate.cert, VerifyOptions{Roots: roots}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyRejectsDelegateWithoutOCSPSigning(t *testing.T) {
+	root := newTestCACert(t, "Root", nil)
+	intermediate := newTestCACert(t, "Intermediate", root)
+	responder := newTestCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "Responder"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, intermediate)
+	roots := x509.NewCertPool()
+	roots.AddCert(root.cert)
+
+	br := newSignedTestResponse(t, intermediate, responder, responder.cert)
+	if err := Verify(br, intermediate.cert, VerifyOptions{Roots: roots}); err != ErrResponderNotAuthorized {
+		t.Fatalf("Verify = %v, want ErrResponderNotAuthorized", err)
+	}
+}
+
+func TestVerifySignerIsSecondEmbeddedCert(t *testing.T) {
+	root := newTestCACert(t, "Root", nil)
+	issuer := newTestCACert(t, "Issuer", root)
+	crossSigned := newTestCACert(t, "Cross-signed", root)
+	responder := newTestResponderCert(t, "Responder", issuer)
+	roots := x509.NewCertPool()
+	roots.AddCert(root.cert)
+
+	br := newSignedTestResponse(t, issuer, responder, crossSigned.cert, responder.cert)
+	certs, err := br.ResponderCertificates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(certs) != 2 {
+		t.Fatalf("ResponderCertificates returned %d certificates, want 2", len(certs))
+	}
+	signer, err := br.responderCertificate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer == nil || !signer.Equal(responder.cert) {
+		t.Fatal("the responder certificate is not the second embedded certificate")
+	}
+	if err := Verify(br, issuer.cert, VerifyOptions{Roots: roots}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyIssuerSignedWithCrossSignedIssuerEmbedded(t *testing.T) {
+	root := newTestCACert(t, "Root", nil)
+	otherRoot := newTestCACert(t, "Other root", nil)
+	issuer := newTestCACert(t, "Issuer", root)
+	// The cross-signed copy has the subject and key of issuer but another issuer and signature.
+	der, err := x509.CreateCertificate(rand.Reader, issuer.cert, otherRoot.cert, issuer.key.Public(), otherRoot.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crossSigned, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(root.cert)
+
+	br := newSignedTestResponse(t, issuer, issuer, crossSigned, issuer.cert)
+	if err := Verify(br, issuer.cert, VerifyOptions{Roots: roots}); err != nil {
+		t.Fatal(err)
+	}
+	br = newSignedTestResponse(t, issuer, issuer)
+	if err := Verify(br, issuer.cert, VerifyOptions{Roots: roots, ExtraResponderCerts: []*x509.Certificate{crossSigned}}); err != nil {
+		t.Fatalf("with the cross-signed issuer in ExtraResponderCerts: %v", err)
+	}
+}
+
+func TestValidateDelegatedResponderKeyUsage(t *testing.T) {
+	issuer := newTestCACert(t, "CA", nil)
+	tests := []struct {
+		name     string
+		keyUsage x509.KeyUsage
+		want     error
+	}{
+		{"digitalSignature", x509.KeyUsageDigitalSignature, nil},
+		{"no key usage extension", 0, nil},
+		{"keyEncipherment only", x509.KeyUsageKeyEncipherment, ErrResponderKeyUsage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			responder := newTestCert(t, &x509.Certificate{
+				SerialNumber: big.NewInt(2),
+				Subject:      pkix.Name{CommonName: "Responder"},
+				KeyUsage:     tt.keyUsage,
+				ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+			}, issuer)
+			if responder.cert.KeyUsage != tt.keyUsage {
+				t.Fatalf("responder key usage = %v, want %v", responder.cert.KeyUsage, tt.keyUsage)
+			}
+			if err := ValidateDelegatedResponder(responder.cert, issuer.cert); err != tt.want {
+				t.Errorf("ValidateDelegatedResponder = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateDelegatedResponderKeyIDMismatch(t *testing.T) {
+	issuer := newTestCACert(t, "CA", nil)
+	responder := newTestResponderCert(t, "Responder", issuer)
+	if err := ValidateDelegatedResponder(responder.cert, issuer.cert); err != nil {
+		t.Fatalf("ValidateDelegatedResponder with the real issuer = %v", err)
+	}
+	// A CA with the same name but another key, and so another SubjectKeyIdentifier.
+	colliding := newTestCACert(t, "CA", nil)
+	if len(responder.cert.AuthorityKeyId) == 0 || len(colliding.cert.SubjectKeyId) == 0 {
+		t.Fatal("the test certificates lack the key identifier extensions")
+	}
+	if err := ValidateDelegatedResponder(responder.cert, colliding.cert); err != ErrResponderKeyIDMismatch {
+		t.Errorf("ValidateDelegatedResponder = %v, want ErrResponderKeyIDMismatch", err)
+	}
+}